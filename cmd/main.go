@@ -6,7 +6,14 @@ import (
 	"os"
 
 	"github.com/VoC925/tgBotNotice/internal/api/telegram"
+	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
 	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/source"
+	"github.com/VoC925/tgBotNotice/internal/source/local"
+	"github.com/VoC925/tgBotNotice/internal/source/s3"
+	"github.com/VoC925/tgBotNotice/internal/source/webdav"
+	"github.com/VoC925/tgBotNotice/internal/storage"
 	"github.com/VoC925/tgBotNotice/pkg/logging"
 	"github.com/VoC925/tgBotNotice/pkg/shutdown"
 	"github.com/VoC925/tgBotNotice/pkg/utils"
@@ -32,8 +39,25 @@ func main() {
 		slog.Error(err.Error())
 		os.Exit(1)
 	}
+	// хранилище состояния сервиса
+	cfg := config.ConfigInstance
+	store, err := storage.NewStorage(cfg.Storage.Type, cfg.Storage.Path)
+	if err != nil {
+		slog.With(
+			slog.Any("error", err),
+		).Error("create storage")
+		os.Exit(1)
+	}
+	// источник событий с файлами
+	src, err := newSource(cfg, store)
+	if err != nil {
+		slog.With(
+			slog.Any("error", err),
+		).Error("create source")
+		os.Exit(1)
+	}
 	// API телеграм бота
-	bot, err := telegram.NewTelegramApi()
+	bot, err := telegram.NewTelegramApi(store, src)
 	if err != nil {
 		slog.With(
 			slog.Any("error", err),
@@ -47,7 +71,8 @@ func main() {
 
 	// горутина, слушащая сигнал ОС и завершающая работу сервиса
 	go func() {
-		if err := shutdown.Shutdown([]os.Signal{os.Interrupt, os.Kill}, bot); err != nil {
+		// опрос источника останавливается первым, чтобы он не писал в закрытое хранилище
+		if err := shutdown.Shutdown([]os.Signal{os.Interrupt, os.Kill}, sourceStopper{src}, bot, store); err != nil {
 			slog.Error(err.Error())
 			os.Exit(1)
 		}
@@ -58,6 +83,56 @@ func main() {
 	slog.Debug("bot stopped")
 }
 
+// обертка, которая при завершении работы останавливает опрос источника
+// канал событий источника не закрывается, так как его читает бот
+type sourceStopper struct {
+	source.Source
+}
+
+func (s sourceStopper) Close() error {
+	s.Stop()
+	return nil
+}
+
+// функция создает источник событий с файлами по типу из конфигурации
+func newSource(cfg *config.Config, store storage.Storage) (source.Source, error) {
+	switch cfg.Source.Type {
+	case source.TypeYandex:
+		// OAuth приложение нужно только Яндекс Диску, остальные источники работают без него
+		if cfg.Telegram.ClientID == "" || cfg.Telegram.ClientSecret == "" {
+			return nil, errorApi.ErrNoOAuthClient
+		}
+		return yandexdisk.NewYandexDiskAPI(store), nil
+	case source.TypeLocal:
+		return local.NewLocalSource(cfg.Source.Local.Folders, cfg.Telegram.TimePauseRequest)
+	case source.TypeWebDAV:
+		return webdav.NewWebDAVSource(
+			cfg.Source.WebDAV.URL,
+			cfg.Source.WebDAV.Username,
+			cfg.Source.WebDAV.Password,
+			cfg.Source.WebDAV.Folders,
+			cfg.Telegram.TimePauseRequest,
+			cfg.Api.Timeout,
+		)
+	case source.TypeS3:
+		return s3.NewS3Source(
+			s3.Options{
+				Endpoint:  cfg.Source.S3.Endpoint,
+				Region:    cfg.Source.S3.Region,
+				Bucket:    cfg.Source.S3.Bucket,
+				AccessKey: cfg.Source.S3.AccessKey,
+				SecretKey: cfg.Source.S3.SecretKey,
+				Prefixes:  cfg.Source.S3.Prefixes,
+				PathStyle: cfg.Source.S3.PathStyle,
+			},
+			cfg.Telegram.TimePauseRequest,
+			cfg.Api.Timeout,
+		)
+	default:
+		return nil, fmt.Errorf("%w: %s", errorApi.ErrUnknownSource, cfg.Source.Type)
+	}
+}
+
 // функция для инициализации логера
 func initLogger(pathToFile string) error {
 	var (
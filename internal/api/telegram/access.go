@@ -0,0 +1,310 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// права на команду или кнопку
+type permission struct {
+	role models.Role // минимальная роль пользователя
+	auth bool        // true - нужна авторизация на Диске
+}
+
+// права на команды, команды без записи в таблице не поддерживаются
+var commandPermissions = map[string]permission{
+	config.InfoCmd:         {role: models.RoleGuest},
+	config.SpecialCmd:      {role: models.RoleGuest},
+	config.RequestCmd:      {role: models.RoleGuest},
+	config.ClaimCmd:        {role: models.RoleGuest},
+	config.SendCmd:         {role: models.RoleMember, auth: true},
+	config.StopCmd:         {role: models.RoleMember, auth: true},
+	config.SubscribeCmd:    {role: models.RoleMember, auth: true},
+	config.UnsubscribeCmd:  {role: models.RoleMember, auth: true},
+	config.UnmuteCmd:       {role: models.RoleMember, auth: true},
+	config.FolderCmd:       {role: models.RoleMember, auth: true},
+	config.LsCmd:           {role: models.RoleMember, auth: true},
+	config.FindCmd:         {role: models.RoleMember, auth: true},
+	config.RecentCmd:       {role: models.RoleMember, auth: true},
+	config.QuotaCmd:        {role: models.RoleMember, auth: true},
+	config.StatusCmd:       {role: models.RoleMember},
+	config.AuthCmd:         {role: models.RoleAdmin},
+	config.DeleteListeners: {role: models.RoleAdmin},
+	config.MembersCmd:      {role: models.RoleAdmin},
+	config.ShareCmd:        {role: models.RoleAdmin, auth: true},
+	config.UnshareCmd:      {role: models.RoleAdmin, auth: true},
+	config.BroadcastCmd:    {role: models.RoleAdmin},
+	config.NotifyCmd:       {role: models.RoleAdmin},
+	config.ListenersCmd:    {role: models.RoleAdmin},
+	config.KickCmd:         {role: models.RoleAdmin},
+	config.PauseCmd:        {role: models.RoleAdmin},
+	config.ResumeCmd:       {role: models.RoleAdmin},
+	config.RoleCmd:         {role: models.RoleOwner},
+}
+
+// права на кнопки под сообщениями
+var actionPermissions = map[string]permission{
+	actionMute:      {role: models.RoleMember},
+	actionUnmute:    {role: models.RoleMember},
+	actionDownload:  {role: models.RoleMember, auth: true},
+	actionList:      {role: models.RoleMember, auth: true},
+	actionFind:      {role: models.RoleMember, auth: true},
+	actionRecent:    {role: models.RoleMember, auth: true},
+	actionLink:      {role: models.RoleAdmin, auth: true},
+	actionUnshare:   {role: models.RoleAdmin, auth: true},
+	actionApprove:   {role: models.RoleAdmin},
+	actionReject:    {role: models.RoleAdmin},
+	actionSend:      {role: models.RoleAdmin},
+	actionCancel:    {role: models.RoleAdmin},
+	actionListeners: {role: models.RoleAdmin},
+}
+
+// запрос доступа, который ждет решения администратора
+type accessRequest struct {
+	chatID int64  // чат, из которого пришел запрос, в него отправляется решение
+	name   string // имя пользователя для администраторов
+}
+
+// метод возвращает роль пользователя по user ID, никнейм не используется, так как его можно сменить
+// владелец и администраторы из конфига не зависят от ролей, выданных в боте
+func (tg *TelegramApi) roleOf(user *tgbotapi.User) models.Role {
+	if user == nil {
+		return models.RoleGuest
+	}
+	if owner := tg.owner.Load(); owner != 0 && user.ID == owner {
+		return models.RoleOwner
+	}
+	tg.muMembers.RLock()
+	role := tg.members[user.ID].Role
+	tg.muMembers.RUnlock()
+	if slices.Contains(tg.admins, user.ID) {
+		return max(role, models.RoleAdmin)
+	}
+	return role
+}
+
+// метод создает секрет для назначения владельца и выводит его в журнал
+func (tg *TelegramApi) newClaimSecret() error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrClaimSecret, err)
+	}
+	secret := hex.EncodeToString(b)
+	tg.muMembers.Lock()
+	tg.claimSecret = secret
+	tg.muMembers.Unlock()
+	slog.With(slog.String("secret", secret)).Warn(fmt.Sprintf("owner is not set, send /%s <secret> to the bot in a private chat", config.ClaimCmd))
+	return nil
+}
+
+// команда /claim <секрет>: пользователь, знающий секрет из журнала, становится владельцем
+// секрет одноразовый, после назначения владельца команда больше не работает
+func (tg *TelegramApi) claim(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	if !msg.Chat.IsPrivate() {
+		// секрет, отправленный в группу, увидят все ее участники
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespClaimPrivate, config.ClaimCmd))
+		return
+	}
+	secret := strings.TrimSpace(msg.CommandArguments())
+	tg.muMembers.Lock()
+	switch {
+	case tg.claimSecret == "":
+		tg.muMembers.Unlock()
+		tg.sendMsg(chatID, config.RespOwnerExists)
+		return
+	case subtle.ConstantTimeCompare([]byte(secret), []byte(tg.claimSecret)) != 1:
+		tg.muMembers.Unlock()
+		slog.With(slog.Int64("user_id", msg.From.ID)).Warn("claim with invalid secret")
+		tg.sendMsg(chatID, config.RespClaimFailed)
+		return
+	}
+	tg.claimSecret = ""
+	tg.muMembers.Unlock()
+
+	tg.owner.Store(msg.From.ID)
+	if err := tg.store.SaveOwner(msg.From.ID); err != nil {
+		slog.With(slog.Any("error", err)).Error("save owner to storage failed")
+	}
+	slog.Info(fmt.Sprintf("user_id: %v; назначен владельцем бота", msg.From.ID))
+	tg.sendMsg(chatID, config.RespOwnerClaimed)
+}
+
+// метод отвечает пользователю, которому не хватает роли required
+func (tg *TelegramApi) denyAccess(chatID int64, role, required models.Role) {
+	switch {
+	case role == models.RoleGuest:
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNoAccess, config.RequestCmd))
+	case required == models.RoleOwner:
+		tg.sendMsg(chatID, config.RespOnlyOwner)
+	default:
+		tg.sendMsg(chatID, config.RespOnlyAdmin)
+	}
+}
+
+// функция возвращает имя пользователя для сообщений администраторам
+func userName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// команда /request: запрос доступа, администраторам приходит сообщение с кнопками
+func (tg *TelegramApi) requestAccess(chatID int64, user *tgbotapi.User) {
+	if role := tg.roleOf(user); role >= models.RoleMember {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespAccessGranted, role))
+		return
+	}
+	name := userName(user)
+	tg.muMembers.Lock()
+	_, pending := tg.requests[user.ID]
+	if !pending {
+		tg.requests[user.ID] = accessRequest{chatID: chatID, name: name}
+	}
+	tg.muMembers.Unlock()
+	if pending {
+		tg.sendMsg(chatID, config.RespAccessPending)
+		return
+	}
+	slog.Info(fmt.Sprintf("chat_id: %v; user_id: %v; запрошен доступ", chatID, user.ID))
+	id := strconv.FormatInt(user.ID, 10)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnApprove, tg.actionKeys.data(actionApprove, id)),
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnReject, tg.actionKeys.data(actionReject, id)),
+	))
+	tg.notifyAdmins(fmt.Sprintf(config.RespAccessRequest, name, user.ID), &keyboard)
+	tg.sendMsg(chatID, config.RespAccessRequested)
+}
+
+// метод выполняет решение администратора по запросу доступа
+func (tg *TelegramApi) decideRequest(chatID int64, p string, approve bool) {
+	userID, err := strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		tg.sendMsg(chatID, config.RespActionExpired)
+		return
+	}
+	tg.muMembers.Lock()
+	request, ok := tg.requests[userID]
+	delete(tg.requests, userID)
+	tg.muMembers.Unlock()
+	if !ok {
+		// запрос уже рассмотрел другой администратор
+		tg.sendMsg(chatID, config.RespRequestHandled)
+		return
+	}
+	if !approve {
+		slog.Info(fmt.Sprintf("user_id: %v; запрос доступа отклонен", userID))
+		tg.sendMsg(request.chatID, config.RespAccessRejected)
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespRequestRejected, request.name))
+		return
+	}
+	tg.setMember(userID, models.Member{Role: models.RoleMember, Name: request.name})
+	tg.sendMsg(request.chatID, fmt.Sprintf(config.RespAccessApproved, config.SendCmd))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespRequestApproved, request.name))
+}
+
+// команда /members: пользователи с доступом
+func (tg *TelegramApi) listMembers(chatID int64) {
+	tg.muMembers.RLock()
+	ids := make([]int64, 0, len(tg.members))
+	for userID := range tg.members {
+		ids = append(ids, userID)
+	}
+	slices.Sort(ids)
+	lines := make([]string, 0, len(ids))
+	for _, userID := range ids {
+		m := tg.members[userID]
+		lines = append(lines, fmt.Sprintf(config.MemberLine, userID, m.Name, m.Role))
+	}
+	tg.muMembers.RUnlock()
+	if len(lines) == 0 {
+		tg.sendMsg(chatID, config.RespNoMembers)
+		return
+	}
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespMembers, strings.Join(lines, "\n")))
+}
+
+// команда /role <user_id> <роль>: выдать или отозвать доступ, роль владельца не выдается
+func (tg *TelegramApi) setRole(chatID int64, arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) != 2 {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespRoleUsage, config.RoleCmd))
+		return
+	}
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	role, ok := models.ParseRole(fields[1])
+	if err != nil || !ok || role == models.RoleOwner {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespRoleUsage, config.RoleCmd))
+		return
+	}
+	tg.muMembers.Lock()
+	m := tg.members[userID]
+	// если пользователь ждал решения, то запрос больше не нужен
+	if request, ok := tg.requests[userID]; ok {
+		m.Name = request.name
+		delete(tg.requests, userID)
+	}
+	tg.muMembers.Unlock()
+	m.Role = role
+	tg.setMember(userID, m)
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespRoleSet, userID, role))
+}
+
+// метод сохраняет роль пользователя, роль guest - удаляет пользователя
+func (tg *TelegramApi) setMember(userID int64, m models.Member) {
+	tg.muMembers.Lock()
+	if m.Role == models.RoleGuest {
+		delete(tg.members, userID)
+	} else {
+		tg.members[userID] = m
+	}
+	tg.muMembers.Unlock()
+	if err := tg.store.SaveMember(userID, m); err != nil {
+		slog.With(slog.Any("error", err)).Error("save member to storage failed")
+	}
+	slog.Info(fmt.Sprintf("user_id: %v; роль изменена на %s", userID, m.Role))
+}
+
+// метод отправляет сообщение владельцу и администраторам в личные чаты
+// у личного чата chat_id совпадает с user ID, поэтому отправка возможна, если пользователь писал боту
+func (tg *TelegramApi) notifyAdmins(text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	var chats []int64
+	if owner := tg.owner.Load(); owner != 0 {
+		chats = append(chats, owner)
+	}
+	for _, userID := range tg.admins {
+		if !slices.Contains(chats, userID) {
+			chats = append(chats, userID)
+		}
+	}
+	tg.muMembers.RLock()
+	for userID, m := range tg.members {
+		if m.Role >= models.RoleAdmin && !slices.Contains(chats, userID) {
+			chats = append(chats, userID)
+		}
+	}
+	tg.muMembers.RUnlock()
+	if len(chats) == 0 {
+		slog.Warn("admin chats are unknown, message not sent")
+		return
+	}
+	for _, chatID := range chats {
+		msg := tgbotapi.NewMessage(chatID, text)
+		if keyboard != nil {
+			msg.ReplyMarkup = *keyboard
+		}
+		tg.send(chatID, msg)
+	}
+}
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// бот без отправки сообщений: очередь не запущена, сообщения остаются в буфере
+func newAccessTestApi() *TelegramApi {
+	tg := &TelegramApi{
+		store:      storage.NewMemoryStorage(),
+		queue:      newOutbox(nil, nil, queueOptions{size: 100}),
+		admins:     []int64{5},
+		members:    map[int64]models.Member{2: {Role: models.RoleAdmin, Name: "@admin"}},
+		requests:   make(map[int64]accessRequest),
+		actionKeys: newActionKeys(actionKeysLimit),
+	}
+	tg.owner.Store(1)
+	return tg
+}
+
+func TestRoleOf(t *testing.T) {
+	tg := newAccessTestApi()
+	assert.Equal(t, models.RoleOwner, tg.roleOf(&tgbotapi.User{ID: 1}))
+	assert.Equal(t, models.RoleAdmin, tg.roleOf(&tgbotapi.User{ID: 2}))
+	assert.Equal(t, models.RoleGuest, tg.roleOf(&tgbotapi.User{ID: 3}))
+	// администратор из конфига
+	assert.Equal(t, models.RoleAdmin, tg.roleOf(&tgbotapi.User{ID: 5}))
+	assert.Equal(t, models.RoleGuest, tg.roleOf(nil))
+	// пока владелец не назначен, владельца нет
+	assert.Equal(t, models.RoleGuest, (&TelegramApi{}).roleOf(&tgbotapi.User{ID: 0}))
+}
+
+func TestCommandPermissions(t *testing.T) {
+	// у каждой команды из справки есть права
+	for _, cmd := range []string{
+		config.InfoCmd, config.AuthCmd, config.SendCmd, config.StopCmd, config.SubscribeCmd,
+		config.UnsubscribeCmd, config.UnmuteCmd, config.ShareCmd, config.UnshareCmd, config.FolderCmd,
+		config.LsCmd, config.FindCmd, config.RecentCmd, config.QuotaCmd, config.StatusCmd, config.RequestCmd,
+		config.MembersCmd, config.RoleCmd, config.ListenersCmd, config.KickCmd, config.PauseCmd, config.ResumeCmd,
+		config.BroadcastCmd, config.NotifyCmd, config.ClaimCmd,
+	} {
+		_, ok := commandPermissions[cmd]
+		assert.True(t, ok, cmd)
+	}
+	assert.Equal(t, models.RoleGuest, commandPermissions[config.RequestCmd].role)
+	assert.Equal(t, models.RoleMember, commandPermissions[config.SendCmd].role)
+	assert.Equal(t, models.RoleAdmin, commandPermissions[config.ShareCmd].role)
+	assert.Equal(t, models.RoleOwner, commandPermissions[config.RoleCmd].role)
+	// рассылки доступны только администраторам, а владельцем можно стать без доступа
+	assert.Equal(t, models.RoleAdmin, commandPermissions[config.BroadcastCmd].role)
+	assert.Equal(t, models.RoleAdmin, commandPermissions[config.NotifyCmd].role)
+	assert.Equal(t, models.RoleGuest, commandPermissions[config.ClaimCmd].role)
+	assert.False(t, commandPermissions[config.ClaimCmd].auth)
+}
+
+func TestAccessRequestApprove(t *testing.T) {
+	tg := newAccessTestApi()
+	guest := &tgbotapi.User{ID: 10, UserName: "guest"}
+
+	tg.requestAccess(-100, guest)
+	require.Contains(t, tg.requests, int64(10))
+	// повторный запрос не создает новый
+	tg.requestAccess(-100, guest)
+	assert.Len(t, tg.requests, 1)
+
+	tg.decideRequest(2, "10", true)
+	assert.Equal(t, models.RoleMember, tg.roleOf(guest))
+	assert.Empty(t, tg.requests)
+	members, err := tg.store.Members()
+	require.NoError(t, err)
+	assert.Equal(t, models.Member{Role: models.RoleMember, Name: "@guest"}, members[10])
+
+	// отзыв доступа владельцем
+	tg.setRole(1, "10 guest")
+	assert.Equal(t, models.RoleGuest, tg.roleOf(guest))
+	members, err = tg.store.Members()
+	require.NoError(t, err)
+	assert.NotContains(t, members, int64(10))
+}
+
+func TestAccessRequestReject(t *testing.T) {
+	tg := newAccessTestApi()
+	guest := &tgbotapi.User{ID: 10, FirstName: "Иван"}
+
+	tg.requestAccess(-100, guest)
+	tg.decideRequest(2, "10", false)
+	assert.Equal(t, models.RoleGuest, tg.roleOf(guest))
+	assert.Empty(t, tg.requests)
+}
+
+func TestClaim(t *testing.T) {
+	tg := newAccessTestApi()
+	tg.owner.Store(0)
+	require.NoError(t, tg.newClaimSecret())
+	secret := tg.claimSecret
+	claim := func(userID int64, chatType, arg string) {
+		tg.claim(&tgbotapi.Message{
+			From:     &tgbotapi.User{ID: userID},
+			Chat:     &tgbotapi.Chat{ID: userID, Type: chatType},
+			Text:     "/claim " + arg,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}},
+		})
+	}
+
+	// в группе и с неверным секретом владелец не назначается
+	claim(7, "group", secret)
+	claim(7, "private", "wrong")
+	assert.Equal(t, int64(0), tg.owner.Load())
+
+	claim(7, "private", secret)
+	assert.Equal(t, models.RoleOwner, tg.roleOf(&tgbotapi.User{ID: 7}))
+	owner, err := tg.store.Owner()
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), owner)
+
+	// секрет одноразовый
+	claim(8, "private", secret)
+	assert.Equal(t, int64(7), tg.owner.Load())
+}
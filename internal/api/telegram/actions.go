@@ -0,0 +1,221 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// действия кнопок под уведомлениями, передаются в callback_data вида "действие:ключ"
+const (
+	actionLink      = "link"    // публичная ссылка на файл
+	actionDownload  = "dl"      // скачать файл
+	actionMute      = "mute"    // скрыть папку
+	actionUnmute    = "unmute"  // вернуть папку
+	actionUnshare   = "unshare" // закрыть доступ по публичной ссылке
+	actionList      = "ls"      // страница содержимого папки
+	actionFind      = "find"    // страница результатов поиска
+	actionRecent    = "recent"  // страница последних загруженных файлов
+	actionApprove   = "approve" // одобрить запрос доступа
+	actionReject    = "reject"  // отклонить запрос доступа
+	actionSend      = "send"    // отправить сообщение администратора после предпросмотра
+	actionCancel    = "cancel"  // отменить отправку сообщения администратора
+	actionListeners = "lst"     // страница списка слушателей
+
+	actionKeysLimit = 10000 // сколько последних путей помнят кнопки
+)
+
+// пути для кнопок
+// в callback_data помещается только 64 байта, поэтому путь заменяется коротким ключом
+// ключи хранятся в памяти, после перезапуска старые кнопки устаревают
+type actionKeys struct {
+	mu    sync.Mutex
+	paths map[string]string // ключ - путь
+	order []string          // ключи в порядке добавления, старые вытесняются
+	limit int
+}
+
+func newActionKeys(limit int) *actionKeys {
+	return &actionKeys{
+		paths: make(map[string]string),
+		limit: limit,
+	}
+}
+
+// метод возвращает callback_data для действия над путем p
+func (k *actionKeys) data(action, p string) string {
+	sum := sha256.Sum256([]byte(p))
+	key := hex.EncodeToString(sum[:6])
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.paths[key]; !ok {
+		k.order = append(k.order, key)
+		if len(k.order) > k.limit {
+			delete(k.paths, k.order[0])
+			k.order = k.order[1:]
+		}
+	}
+	k.paths[key] = p
+	return action + ":" + key
+}
+
+// метод забывает ключ из callback_data, чтобы кнопку нельзя было нажать повторно
+// false - ключ уже забыт, например кнопку нажали дважды
+func (k *actionKeys) forget(data string) bool {
+	_, key, _ := strings.Cut(data, ":")
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.paths[key]; !ok {
+		return false
+	}
+	delete(k.paths, key)
+	return true
+}
+
+// метод разбирает callback_data, false - ключ неизвестен или устарел
+func (k *actionKeys) parse(data string) (string, string, bool) {
+	action, key, ok := strings.Cut(data, ":")
+	if !ok {
+		return "", "", false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.paths[key]
+	return action, p, ok
+}
+
+// метод возвращает кнопки для файлов уведомления, nil - кнопок нет
+// offset - сколько файлов было в предыдущих сообщениях, нужен для номеров файлов
+// many - в уведомлении несколько файлов, кнопки подписываются номером файла
+func (tg *TelegramApi) keyboard(offset int, items models.UpdateInfoSlice, many bool) *tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for index, elem := range items {
+		label := func(text string) string {
+			if many {
+				return fmt.Sprintf(config.BtnIndex, offset+index+1, text)
+			}
+			return text
+		}
+		var row []tgbotapi.InlineKeyboardButton
+		// ссылка и скачивание есть только у существующих файлов Яндекс Диска
+		if tg.yandexApi != nil && elem.Type == "file" && elem.Event != models.EventDeleted {
+			row = append(row,
+				tgbotapi.NewInlineKeyboardButtonData(label(config.BtnPublicLink), tg.actionKeys.data(actionLink, elem.Path)),
+				tgbotapi.NewInlineKeyboardButtonData(label(config.BtnDownload), tg.actionKeys.data(actionDownload, elem.Path)),
+			)
+		}
+		folder := path.Dir(models.StripScheme(elem.Path))
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData(label(config.BtnMute), tg.actionKeys.data(actionMute, folder)),
+		)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// метод обрабатывает нажатие кнопки под уведомлением
+func (tg *TelegramApi) handleCallback(q *tgbotapi.CallbackQuery) {
+	if q.Message == nil {
+		tg.answerCallback(q.ID, "")
+		return
+	}
+	chatID := q.Message.Chat.ID
+	slog.Info(fmt.Sprintf("chat_id: %v; нажата кнопка %s", chatID, q.Data))
+	action, p, ok := tg.actionKeys.parse(q.Data)
+	if !ok {
+		tg.answerCallback(q.ID, config.RespActionExpired)
+		return
+	}
+	perm, ok := actionPermissions[action]
+	if !ok {
+		tg.answerCallback(q.ID, config.RespUnknownCmd)
+		return
+	}
+	if role := tg.roleOf(q.From); role < perm.role {
+		if role == models.RoleGuest {
+			tg.answerCallback(q.ID, fmt.Sprintf(config.RespNoAccess, config.RequestCmd))
+		} else {
+			tg.answerCallback(q.ID, config.RespOnlyAdmin)
+		}
+		return
+	}
+	// действия с файлами выполняются через API Яндекс Диска
+	if perm.auth && (tg.yandexApi == nil || !tg.isAuthorized()) {
+		tg.answerCallback(q.ID, config.RespNeedAuth)
+		return
+	}
+	switch action {
+	case actionMute:
+		tg.answerCallback(q.ID, "")
+		tg.mute(chatID, p)
+	case actionUnmute:
+		tg.answerCallback(q.ID, "")
+		tg.unmute(chatID, p)
+	case actionApprove, actionReject:
+		tg.answerCallback(q.ID, "")
+		tg.decideRequest(chatID, p, action == actionApprove)
+	case actionSend, actionCancel:
+		// у кнопок отправки и отмены общий ключ, поэтому сработает только первое нажатие
+		if !tg.actionKeys.forget(q.Data) {
+			tg.answerCallback(q.ID, config.RespActionExpired)
+			return
+		}
+		tg.answerCallback(q.ID, "")
+		tg.confirmBroadcast(chatID, q.Message.MessageID, p, action == actionSend)
+	case actionLink:
+		tg.answerCallback(q.ID, "")
+		tg.publicLink(chatID, p)
+	case actionUnshare:
+		tg.answerCallback(q.ID, "")
+		tg.unpublish(chatID, p)
+	case actionDownload:
+		tg.answerCallback(q.ID, config.RespDownloadStarted)
+		tg.downloadFile(chatID, p)
+	case actionList, actionFind, actionRecent, actionListeners:
+		tg.answerCallback(q.ID, "")
+		tg.turnPage(chatID, q.Message.MessageID, action, p)
+	}
+}
+
+// метод отвечает на нажатие кнопки, text - всплывающее уведомление, пустой - без уведомления
+// ответ отправляется напрямую, минуя очередь, так как Telegram ждет его несколько секунд
+func (tg *TelegramApi) answerCallback(id, text string) {
+	if _, err := tg.bot.Request(tgbotapi.NewCallback(id, text)); err != nil {
+		slog.With(slog.Any("error", err)).Error("answer callback query failed")
+	}
+}
+
+// метод отправляет файл в чат документом, если файл больше лимита - отправляет ссылку на скачивание
+func (tg *TelegramApi) downloadFile(chatID int64, filePath string) {
+	name := path.Base(models.StripScheme(filePath))
+	content, err := tg.yandexApi.Download(filePath, tg.mediaMaxSize)
+	switch {
+	case errors.Is(err, errorApi.ErrFileTooLarge):
+		link, err := tg.yandexApi.DownloadURL(filePath)
+		if err != nil {
+			slog.Error(err.Error())
+			tg.sendMsg(chatID, config.RespActionFailed)
+			return
+		}
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespDownloadLink, name, link))
+	case err != nil:
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespActionFailed)
+	default:
+		tg.send(chatID, tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: content}))
+	}
+}
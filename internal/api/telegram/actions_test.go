@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionKeys(t *testing.T) {
+	k := newActionKeys(2)
+	long := "disk:/Очень длинное название папки/и еще более длинное название файла.docx"
+	data := k.data(actionLink, long)
+	assert.LessOrEqual(t, len(data), 64)
+
+	action, p, ok := k.parse(data)
+	require.True(t, ok)
+	assert.Equal(t, actionLink, action)
+	assert.Equal(t, long, p)
+
+	// старые ключи вытесняются новыми
+	k.data(actionMute, "/a")
+	k.data(actionMute, "/b")
+	_, _, ok = k.parse(data)
+	assert.False(t, ok)
+	_, _, ok = k.parse("garbage")
+	assert.False(t, ok)
+}
+
+func TestFilterForChatMutes(t *testing.T) {
+	tg := &TelegramApi{
+		subscriptions: map[int64][]string{2: {"/Общее"}},
+		mutes:         map[int64][]string{1: {"/Общее/Фото"}, 2: {"/Общее/Фото"}},
+	}
+	data := &models.UpdateInfoSlice{
+		{Path: "disk:/Общее/a.txt"},
+		{Path: "disk:/Общее/Фото/b.jpg"},
+		{Path: "disk:/Личное/c.txt"},
+	}
+
+	paths := func(items models.UpdateInfoSlice) []string {
+		var res []string
+		for _, elem := range items {
+			res = append(res, elem.Path)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"disk:/Общее/a.txt", "disk:/Личное/c.txt"}, paths(tg.filterForChat(1, data)))
+	assert.Equal(t, []string{"disk:/Общее/a.txt"}, paths(tg.filterForChat(2, data)))
+	assert.Len(t, tg.filterForChat(3, data), 3)
+}
+
+func TestDiskPath(t *testing.T) {
+	assert.Equal(t, "disk:/Общее/a.txt", diskPath("/Общее/a.txt"))
+	assert.Equal(t, "disk:/Общее/a.txt", diskPath(" Общее/a.txt "))
+	assert.Equal(t, "disk:/Общее/a.txt", diskPath("disk:/Общее/a.txt"))
+}
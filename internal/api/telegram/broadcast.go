@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// получатель "все активные слушатели" в черновике сообщения
+// черновик хранится в кнопках предпросмотра в виде "номер|получатель|текст"
+// номер уникален для каждого предпросмотра, поэтому одинаковые черновики не делят кнопки
+const broadcastAll = "all"
+
+// команда /broadcast <текст>: сообщение всем слушателям, которые читают уведомления
+func (tg *TelegramApi) broadcast(chatID int64, arg string) {
+	text := strings.TrimSpace(arg)
+	if text == "" {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespBroadcastUsage, config.BroadcastCmd))
+		return
+	}
+	n := len(tg.activeListeners())
+	if n == 0 {
+		tg.sendMsg(chatID, config.RespNoActiveListeners)
+		return
+	}
+	tg.preview(chatID, broadcastAll, fmt.Sprintf(config.TargetListeners, n), text)
+}
+
+// команда /notify <chat_id|@группа> <текст>: сообщение в один чат
+func (tg *TelegramApi) notify(chatID int64, arg string) {
+	target, text, _ := strings.Cut(strings.TrimSpace(arg), " ")
+	text = strings.TrimSpace(text)
+	if target == "" || text == "" {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNotifyUsage, config.NotifyCmd, config.NotifyCmd))
+		return
+	}
+	chat, err := tg.resolveChat(target)
+	if err != nil {
+		slog.With(slog.String("chat", target), slog.Any("error", err)).Warn("resolve chat failed")
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespChatNotFound, target))
+		return
+	}
+	tg.preview(chatID, strconv.FormatInt(chat.ID, 10), fmt.Sprintf(config.TargetChat, chatTitle(chat)), text)
+}
+
+// метод находит чат по chat_id или публичному имени @группа
+func (tg *TelegramApi) resolveChat(target string) (tgbotapi.Chat, error) {
+	info := tgbotapi.ChatInfoConfig{}
+	if id, err := strconv.ParseInt(target, 10, 64); err == nil {
+		info.ChatID = id
+	} else if strings.HasPrefix(target, "@") {
+		info.SuperGroupUsername = target
+	} else {
+		return tgbotapi.Chat{}, errorApi.ErrInvalidChat
+	}
+	return tg.bot.GetChat(info)
+}
+
+// функция возвращает название чата для администратора: название группы или имя пользователя и chat_id
+func chatTitle(chat tgbotapi.Chat) string {
+	title := chatName(chat)
+	// о чате ничего не известно, кроме chat_id
+	if title == "" {
+		return strconv.FormatInt(chat.ID, 10)
+	}
+	return fmt.Sprintf("%s (%d)", title, chat.ID)
+}
+
+// функция возвращает название группы, @username или имя пользователя, пустая строка - название неизвестно
+func chatName(chat tgbotapi.Chat) string {
+	switch {
+	case chat.Title != "":
+		return chat.Title
+	case chat.UserName != "":
+		return "@" + chat.UserName
+	default:
+		return strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	}
+}
+
+// метод отправляет администратору предпросмотр сообщения с кнопками отправки и отмены
+func (tg *TelegramApi) preview(chatID int64, target, who, text string) {
+	draft := fmt.Sprintf("%d|%s|%s", tg.draftSeq.Add(1), target, text)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(config.RespBroadcastPreview, who, text))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnSend, tg.actionKeys.data(actionSend, draft)),
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnCancel, tg.actionKeys.data(actionCancel, draft)),
+	))
+	tg.send(chatID, msg)
+}
+
+// метод выполняет решение администратора по предпросмотру: отправляет сообщение или отменяет отправку
+func (tg *TelegramApi) confirmBroadcast(chatID int64, messageID int, draft string, confirmed bool) {
+	// кнопки предпросмотра больше не нужны
+	tg.send(chatID, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+	}))
+	if !confirmed {
+		tg.sendMsg(chatID, config.RespBroadcastCanceled)
+		return
+	}
+	target, text := parseDraft(draft)
+	var chats []int64
+	if target == broadcastAll {
+		chats = tg.activeListeners()
+	} else if id, err := strconv.ParseInt(target, 10, 64); err == nil {
+		chats = []int64{id}
+	}
+	if len(chats) == 0 {
+		tg.sendMsg(chatID, config.RespNoActiveListeners)
+		return
+	}
+	slog.Info(fmt.Sprintf("chat_id: %v; отправка сообщения администратора, получателей: %d", chatID, len(chats)))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespBroadcastStarted, len(chats)))
+	tg.deliverReport(chatID, chats, text)
+}
+
+// функция разбирает черновик "номер|получатель|текст" на получателя и текст
+func parseDraft(draft string) (string, string) {
+	_, draft, _ = strings.Cut(draft, "|")
+	target, text, _ := strings.Cut(draft, "|")
+	return target, text
+}
+
+// метод отправляет текст в чаты через очередь, дожидается результата и отправляет отчет администратору
+func (tg *TelegramApi) deliverReport(adminChat int64, chats []int64, text string) {
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int64
+	)
+	for _, chatID := range chats {
+		wg.Add(1)
+		tg.sendReport(chatID, tgbotapi.NewMessage(chatID, text), func(err error) {
+			if err != nil {
+				failed.Add(1)
+			}
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	tg.sendMsg(adminChat, fmt.Sprintf(config.RespBroadcastReport, int64(len(chats))-failed.Load(), failed.Load()))
+}
+
+// метод возвращает чаты, которые читают уведомления
+func (tg *TelegramApi) activeListeners() []int64 {
+	tg.mu.RLock()
+	chats := make([]int64, 0, len(tg.listeners))
+	for chatID, state := range tg.listeners {
+		if state {
+			chats = append(chats, chatID)
+		}
+	}
+	tg.mu.RUnlock()
+	slices.Sort(chats)
+	return chats
+}
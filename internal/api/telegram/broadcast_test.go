@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// заглушка отправки, запоминает текстовые сообщения и не доставляет их в чаты из blocked
+type recordSender struct {
+	mu      sync.Mutex
+	blocked map[int64]bool
+	texts   map[int64][]string
+}
+
+func (r *recordSender) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	msg, ok := c.(tgbotapi.MessageConfig)
+	if !ok {
+		return tgbotapi.Message{}, nil
+	}
+	if r.blocked[msg.ChatID] {
+		return tgbotapi.Message{}, &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.texts[msg.ChatID] = append(r.texts[msg.ChatID], msg.Text)
+	return tgbotapi.Message{}, nil
+}
+
+func TestDeliverReport(t *testing.T) {
+	sender := &recordSender{blocked: map[int64]bool{2: true}, texts: make(map[int64][]string)}
+	tg := &TelegramApi{
+		store:     storage.NewMemoryStorage(),
+		listeners: map[int64]bool{1: true, 2: true, 3: false},
+	}
+	tg.queue = newOutbox(sender.send, noFail, testQueueOptions())
+	tg.queue.start()
+
+	chats := tg.activeListeners()
+	require.Equal(t, []int64{1, 2}, chats)
+	tg.deliverReport(100, chats, "Плановые работы")
+	tg.queue.close(testQueueOptions().backoffMax * 10)
+
+	assert.Equal(t, []string{"Плановые работы"}, sender.texts[1])
+	assert.NotContains(t, sender.texts, int64(3))
+	assert.Equal(t, []string{"Отправка завершена: доставлено 1, не доставлено 1"}, sender.texts[100])
+}
+
+func TestChatTitle(t *testing.T) {
+	assert.Equal(t, "Отдел (-100)", chatTitle(tgbotapi.Chat{ID: -100, Title: "Отдел"}))
+	assert.Equal(t, "@user (5)", chatTitle(tgbotapi.Chat{ID: 5, UserName: "user"}))
+	assert.Equal(t, "Иван Петров (6)", chatTitle(tgbotapi.Chat{ID: 6, FirstName: "Иван", LastName: "Петров"}))
+}
+
+// заглушка отправки, запоминает отправленные сообщения
+type previewSender struct {
+	mu   sync.Mutex
+	msgs []tgbotapi.MessageConfig
+}
+
+func (p *previewSender) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		p.mu.Lock()
+		p.msgs = append(p.msgs, msg)
+		p.mu.Unlock()
+	}
+	return tgbotapi.Message{}, nil
+}
+
+func TestPreviewUniqueDraft(t *testing.T) {
+	sender := &previewSender{}
+	tg := &TelegramApi{actionKeys: newActionKeys(actionKeysLimit)}
+	tg.queue = newOutbox(sender.send, noFail, testQueueOptions())
+	tg.queue.start()
+	tg.preview(1, broadcastAll, "всем", "Плановые работы")
+	tg.preview(1, broadcastAll, "всем", "Плановые работы")
+	tg.queue.close(testQueueOptions().backoffMax * 10)
+
+	require.Len(t, sender.msgs, 2)
+	button := func(msg tgbotapi.MessageConfig) string {
+		return *msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0][0].CallbackData
+	}
+	first, second := button(sender.msgs[0]), button(sender.msgs[1])
+	// у одинаковых черновиков разные ключи: ответ на один предпросмотр не затрагивает другой
+	assert.NotEqual(t, first, second)
+	require.True(t, tg.actionKeys.forget(first))
+	_, draft, ok := tg.actionKeys.parse(second)
+	require.True(t, ok)
+	target, text := parseDraft(draft)
+	assert.Equal(t, broadcastAll, target)
+	assert.Equal(t, "Плановые работы", text)
+}
@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/notice"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	browsePageSize = 10               // элементов на одной странице списка
+	findLimit      = 100              // максимальное количество результатов поиска
+	findCacheTTL   = 30 * time.Minute // сколько хранятся результаты поиска для листания страниц
+	recentDefault  = 10               // сколько последних файлов показывать, если количество не указано
+	recentMax      = 100              // максимальное количество последних файлов
+)
+
+// команда /ls [папка]: содержимое папки, без аргумента - корень Диска
+func (tg *TelegramApi) list(chatID int64, arg string) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+		return
+	}
+	tg.showPage(chatID, 0, actionList, models.NormalizeFolder(arg), 0)
+}
+
+// команда /find <имя>: поиск файлов по части имени
+func (tg *TelegramApi) find(chatID int64, arg string) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+		return
+	}
+	name := strings.TrimSpace(arg)
+	if name == "" {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNeedName, config.FindCmd))
+		return
+	}
+	// новый поиск не берет результаты прошлого поиска из кэша
+	tg.forgetFind(chatID, name)
+	tg.showPage(chatID, 0, actionFind, name, 0)
+}
+
+// команда /recent [n]: последние n загруженных файлов
+func (tg *TelegramApi) recent(chatID int64, arg string) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+		return
+	}
+	n := recentDefault
+	if arg = strings.TrimSpace(arg); arg != "" {
+		var err error
+		n, err = strconv.Atoi(arg)
+		if err != nil || n < 1 || n > recentMax {
+			tg.sendMsg(chatID, fmt.Sprintf(config.RespNeedCount, recentMax, config.RecentCmd))
+			return
+		}
+	}
+	tg.showPage(chatID, 0, actionRecent, strconv.Itoa(n), 0)
+}
+
+// метод показывает другую страницу списка по нажатию кнопки
+// p - состояние страницы в виде "смещение|аргумент команды"
+func (tg *TelegramApi) turnPage(chatID int64, messageID int, action, p string) {
+	rawOffset, arg, _ := strings.Cut(p, "|")
+	offset, err := strconv.Atoi(rawOffset)
+	if err != nil {
+		tg.sendMsg(chatID, config.RespActionExpired)
+		return
+	}
+	tg.showPage(chatID, messageID, action, arg, offset)
+}
+
+// метод отправляет страницу списка, messageID - сообщение, которое нужно заменить, 0 - отправить новое
+func (tg *TelegramApi) showPage(chatID int64, messageID int, action, arg string, offset int) {
+	text, total, err := tg.page(chatID, action, arg, offset)
+	if err != nil {
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespActionFailed)
+		return
+	}
+	keyboard := tg.pageKeyboard(action, arg, offset, total)
+	if messageID == 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		if keyboard != nil {
+			msg.ReplyMarkup = *keyboard
+		}
+		tg.send(chatID, msg)
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = keyboard
+	tg.send(chatID, edit)
+}
+
+// метод запрашивает страницу списка у Диска или из слушателей и возвращает ее текст и общее количество элементов
+// chatID - чат, для которого сохраняются результаты поиска
+func (tg *TelegramApi) page(chatID int64, action, arg string, offset int) (string, int, error) {
+	switch action {
+	case actionList:
+		items, total, err := tg.yandexApi.List(models.DiskPrefix+arg, offset, browsePageSize)
+		if err != nil {
+			return "", 0, err
+		}
+		if total == 0 {
+			return fmt.Sprintf(config.RespListEmpty, arg), 0, nil
+		}
+		return fmt.Sprintf(config.RespList, arg, offset+1, offset+len(items), total, browseLines(items, false)), total, nil
+	case actionFind:
+		items, err := tg.findItems(chatID, arg)
+		if err != nil {
+			return "", 0, err
+		}
+		if len(items) == 0 {
+			return fmt.Sprintf(config.RespNotFound, arg), 0, nil
+		}
+		page := pageOf(items, offset)
+		return fmt.Sprintf(config.RespFound, arg, offset+1, offset+len(page), len(items), browseLines(page, true)), len(items), nil
+	case actionRecent:
+		n, _ := strconv.Atoi(arg)
+		items, err := tg.yandexApi.Recent(n)
+		if err != nil {
+			return "", 0, err
+		}
+		if len(items) == 0 {
+			return config.RespNoRecent, 0, nil
+		}
+		page := pageOf(items, offset)
+		return fmt.Sprintf(config.RespRecent, offset+1, offset+len(page), len(items), browseLines(page, true)), len(items), nil
+	case actionListeners:
+		return tg.listenersPage(offset)
+	}
+	return config.RespUnknownCmd, 0, nil
+}
+
+// метод возвращает кнопки перехода между страницами, nil - список помещается на одну страницу
+func (tg *TelegramApi) pageKeyboard(action, arg string, offset, total int) *tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		prev := max(offset-browsePageSize, 0)
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(config.BtnPrev, tg.actionKeys.data(action, fmt.Sprintf("%d|%s", prev, arg))))
+	}
+	if offset+browsePageSize < total {
+		next := offset + browsePageSize
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(config.BtnNext, tg.actionKeys.data(action, fmt.Sprintf("%d|%s", next, arg))))
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &keyboard
+}
+
+// функция возвращает страницу из уже полученного списка
+func pageOf(items models.UpdateInfoSlice, offset int) models.UpdateInfoSlice {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+browsePageSize, len(items))]
+}
+
+// функция формирует строки списка, fullPath - показывать путь вместо имени
+func browseLines(items models.UpdateInfoSlice, fullPath bool) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		name := item.Title
+		if fullPath {
+			name = models.StripScheme(item.Path)
+		}
+		switch {
+		case item.Type == "dir":
+			lines = append(lines, fmt.Sprintf(config.ListDir, name))
+		case item.Size > 0:
+			lines = append(lines, fmt.Sprintf(config.ListSize, name, notice.HumanSize(item.Size)))
+		default:
+			lines = append(lines, fmt.Sprintf(config.ListFile, name))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// результаты поиска, ключ - чат и строка поиска
+type findKey struct {
+	chatID int64
+	name   string
+}
+
+// найденные файлы и время поиска
+type findResult struct {
+	items models.UpdateInfoSlice
+	at    time.Time
+}
+
+// метод возвращает результаты поиска name для чата
+// поиск просматривает весь Диск, поэтому результаты сохраняются и листание страниц не повторяет его
+func (tg *TelegramApi) findItems(chatID int64, name string) (models.UpdateInfoSlice, error) {
+	key := findKey{chatID: chatID, name: name}
+	tg.muFind.Lock()
+	result, ok := tg.finds[key]
+	tg.muFind.Unlock()
+	if ok && time.Since(result.at) < findCacheTTL {
+		return result.items, nil
+	}
+	items, err := tg.yandexApi.Find(name, findLimit)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tg.muFind.Lock()
+	defer tg.muFind.Unlock()
+	if tg.finds == nil {
+		tg.finds = make(map[findKey]findResult)
+	}
+	// устаревшие результаты удаляются, чтобы кэш не рос
+	for k, r := range tg.finds {
+		if now.Sub(r.at) >= findCacheTTL {
+			delete(tg.finds, k)
+		}
+	}
+	tg.finds[key] = findResult{items: items, at: now}
+	return items, nil
+}
+
+// метод забывает сохраненные результаты поиска name для чата
+func (tg *TelegramApi) forgetFind(chatID int64, name string) {
+	tg.muFind.Lock()
+	delete(tg.finds, findKey{chatID: chatID, name: name})
+	tg.muFind.Unlock()
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// заглушка Диска со списком файлов
+type browseYandexApi struct {
+	yandexdisk.YandexDiskApi
+	files models.UpdateInfoSlice
+}
+
+func (s browseYandexApi) Recent(limit int) (models.UpdateInfoSlice, error) {
+	return s.files[:min(limit, len(s.files))], nil
+}
+
+func TestBrowsePages(t *testing.T) {
+	var files models.UpdateInfoSlice
+	for i := 1; i <= 25; i++ {
+		files = append(files, &models.UpdateInfo{
+			Title: fmt.Sprintf("%d.txt", i),
+			Path:  fmt.Sprintf("disk:/Общее/%d.txt", i),
+			Type:  "file",
+			Size:  2048,
+		})
+	}
+	tg := &TelegramApi{
+		yandexApi:  browseYandexApi{files: files},
+		actionKeys: newActionKeys(actionKeysLimit),
+	}
+
+	text, total, err := tg.page(1, actionRecent, "25", 20)
+	require.NoError(t, err)
+	assert.Equal(t, 25, total)
+	assert.Equal(t, "Последние загруженные файлы, 21-25 из 25:\n📄 /Общее/21.txt, 2.0 КБ\n📄 /Общее/22.txt, 2.0 КБ\n"+
+		"📄 /Общее/23.txt, 2.0 КБ\n📄 /Общее/24.txt, 2.0 КБ\n📄 /Общее/25.txt, 2.0 КБ", text)
+
+	// на первой странице только кнопка "вперед"
+	keyboard := tg.pageKeyboard(actionRecent, "25", 0, total)
+	require.NotNil(t, keyboard)
+	require.Len(t, keyboard.InlineKeyboard[0], 1)
+	action, p, ok := tg.actionKeys.parse(*keyboard.InlineKeyboard[0][0].CallbackData)
+	require.True(t, ok)
+	assert.Equal(t, actionRecent, action)
+	assert.Equal(t, "10|25", p)
+
+	// на последней странице только кнопка "назад"
+	keyboard = tg.pageKeyboard(actionRecent, "25", 20, total)
+	require.NotNil(t, keyboard)
+	require.Len(t, keyboard.InlineKeyboard[0], 1)
+	_, p, _ = tg.actionKeys.parse(*keyboard.InlineKeyboard[0][0].CallbackData)
+	assert.Equal(t, "10|25", p)
+
+	// список на одну страницу без кнопок
+	assert.Nil(t, tg.pageKeyboard(actionRecent, "5", 0, 5))
+}
+
+// заглушка Диска, которая считает поиски
+type findYandexApi struct {
+	yandexdisk.YandexDiskApi
+	files models.UpdateInfoSlice
+	calls int
+}
+
+func (s *findYandexApi) Find(name string, limit int) (models.UpdateInfoSlice, error) {
+	s.calls++
+	return s.files, nil
+}
+
+func TestFindPagesCached(t *testing.T) {
+	var files models.UpdateInfoSlice
+	for i := 1; i <= 25; i++ {
+		files = append(files, &models.UpdateInfo{Title: fmt.Sprintf("%d.txt", i), Path: fmt.Sprintf("disk:/%d.txt", i), Type: "file"})
+	}
+	api := &findYandexApi{files: files}
+	tg := &TelegramApi{yandexApi: api}
+
+	for _, offset := range []int{0, 10, 20, 10} {
+		_, total, err := tg.page(1, actionFind, "txt", offset)
+		require.NoError(t, err)
+		assert.Equal(t, 25, total)
+	}
+	// листание страниц не повторяет поиск
+	assert.Equal(t, 1, api.calls)
+
+	// у другого чата свои результаты
+	_, _, err := tg.page(2, actionFind, "txt", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, api.calls)
+
+	// новая команда /find ищет заново
+	tg.forgetFind(1, "txt")
+	_, _, err = tg.page(1, actionFind, "txt", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, api.calls)
+}
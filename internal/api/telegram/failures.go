@@ -0,0 +1,216 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// причина, по которой в чат больше нельзя отправлять сообщения, или ошибка, которую не нужно считать ошибкой
+type chatFailure int
+
+const (
+	failureNone        chatFailure = iota // ошибка не связана с чатом (сеть, лимиты, ошибка сервера)
+	failureBlocked                        // пользователь заблокировал бота
+	failureKicked                         // бота удалили из группы или канала
+	failureDeactivated                    // аккаунт пользователя удален
+	failureNotFound                       // чат не найден (например, группа удалена)
+	failureMigrated                       // группа преобразована в супергруппу с новым chat_id
+	failureNotModified                    // правка не меняет сообщение (повторное нажатие кнопки страницы)
+)
+
+func (f chatFailure) String() string {
+	switch f {
+	case failureBlocked:
+		return "blocked"
+	case failureKicked:
+		return "kicked"
+	case failureDeactivated:
+		return "deactivated"
+	case failureNotFound:
+		return "chat not found"
+	case failureMigrated:
+		return "migrated"
+	case failureNotModified:
+		return "not modified"
+	default:
+		return "none"
+	}
+}
+
+// функция определяет по ошибке отправки, что случилось с чатом
+// для failureMigrated также возвращается новый chat_id
+func classifySendError(err error) (chatFailure, int64) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return failureNone, 0
+	}
+	if tgErr.MigrateToChatID != 0 {
+		return failureMigrated, tgErr.MigrateToChatID
+	}
+	desc := strings.ToLower(tgErr.Message)
+	switch tgErr.Code {
+	case http.StatusForbidden:
+		switch {
+		case strings.Contains(desc, "blocked"):
+			return failureBlocked, 0
+		case strings.Contains(desc, "deactivated"):
+			return failureDeactivated, 0
+		case strings.Contains(desc, "kicked"), strings.Contains(desc, "not a member"):
+			return failureKicked, 0
+		}
+	case http.StatusBadRequest:
+		switch {
+		case strings.Contains(desc, "chat not found"):
+			return failureNotFound, 0
+		case strings.Contains(desc, "message is not modified"):
+			return failureNotModified, 0
+		}
+	}
+	return failureNone, 0
+}
+
+// метод обновляет слушателей после ошибки отправки в чат
+// возвращает true, если сообщение было переотправлено в новый чат или правка ничего не меняла
+func (tg *TelegramApi) handleSendFailure(m outMsg, err error) bool {
+	failure, newChatID := classifySendError(err)
+	switch failure {
+	case failureNone:
+		return false
+	case failureNotModified:
+		// в сообщении уже нужный текст, правка считается доставленной
+		return true
+	case failureMigrated:
+		tg.migrateListener(m.chatID, newChatID)
+		// сообщение переотправляется в супергруппу
+		// постановка в очередь в горутине, так как метод вызывается обработчиком очереди
+		c, ok := retarget(m.msg, newChatID)
+		if !ok {
+			return false
+		}
+		if c != nil {
+			go tg.send(newChatID, c)
+		}
+		return true
+	default:
+		slog.With(
+			slog.Int64("chat_id", m.chatID),
+			slog.String("reason", failure.String()),
+		).Info("chat is unreachable, listener removed")
+		tg.removeListener(m.chatID)
+		return false
+	}
+}
+
+// функция возвращает копию сообщения c для чата chatID
+// правка текста отправляется новым сообщением, так как старого сообщения в новом чате нет,
+// а правку кнопок отправлять не нужно - возвращается nil
+// false - сообщение такого типа нельзя переотправить
+func retarget(c tgbotapi.Chattable, chatID int64) (tgbotapi.Chattable, bool) {
+	switch msg := c.(type) {
+	case tgbotapi.MessageConfig:
+		msg.ChatID = chatID
+		return msg, true
+	case tgbotapi.PhotoConfig:
+		msg.ChatID = chatID
+		return msg, true
+	case tgbotapi.DocumentConfig:
+		msg.ChatID = chatID
+		return msg, true
+	case tgbotapi.MediaGroupConfig:
+		msg.ChatID = chatID
+		return msg, true
+	case tgbotapi.EditMessageTextConfig:
+		text := tgbotapi.NewMessage(chatID, msg.Text)
+		text.ParseMode = msg.ParseMode
+		text.Entities = msg.Entities
+		text.DisableWebPagePreview = msg.DisableWebPagePreview
+		if msg.ReplyMarkup != nil {
+			text.ReplyMarkup = *msg.ReplyMarkup
+		}
+		return text, true
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return nil, true
+	}
+	return nil, false
+}
+
+// метод удаляет слушателя вместе с подписками
+func (tg *TelegramApi) removeListener(chatID int64) {
+	tg.mu.Lock()
+	delete(tg.listeners, chatID)
+	delete(tg.subscriptions, chatID)
+	delete(tg.mutes, chatID)
+	delete(tg.uploadFolders, chatID)
+	tg.mu.Unlock()
+	tg.muChats.Lock()
+	delete(tg.chats, chatID)
+	delete(tg.deliverySaved, chatID)
+	delete(tg.notices, chatID)
+	tg.muChats.Unlock()
+	if err := tg.store.DeleteListener(chatID); err != nil {
+		slog.With(slog.Any("error", err)).Error("delete listener from storage failed")
+	}
+}
+
+// метод переносит слушателя и его подписки на новый chat_id
+func (tg *TelegramApi) migrateListener(oldID, newID int64) {
+	tg.mu.Lock()
+	state, ok := tg.listeners[oldID]
+	if !ok {
+		tg.mu.Unlock()
+		return
+	}
+	folders := tg.subscriptions[oldID]
+	mutes := tg.mutes[oldID]
+	uploadFolder := tg.uploadFolders[oldID]
+	delete(tg.listeners, oldID)
+	delete(tg.subscriptions, oldID)
+	delete(tg.mutes, oldID)
+	delete(tg.uploadFolders, oldID)
+	tg.listeners[newID] = state
+	if len(folders) > 0 {
+		tg.subscriptions[newID] = folders
+	}
+	if len(mutes) > 0 {
+		tg.mutes[newID] = mutes
+	}
+	if uploadFolder != "" {
+		tg.uploadFolders[newID] = uploadFolder
+	}
+	tg.mu.Unlock()
+
+	if err := tg.store.SaveListener(newID, state); err != nil {
+		slog.With(slog.Any("error", err)).Error("save listener to storage failed")
+	}
+	if err := tg.store.SaveSubscriptions(newID, folders); err != nil {
+		slog.With(slog.Any("error", err)).Error("save subscriptions to storage failed")
+	}
+	if err := tg.store.SaveMutes(newID, mutes); err != nil {
+		slog.With(slog.Any("error", err)).Error("save mutes to storage failed")
+	}
+	if err := tg.store.SaveUploadFolder(newID, uploadFolder); err != nil {
+		slog.With(slog.Any("error", err)).Error("save upload folder to storage failed")
+	}
+	if err := tg.store.DeleteListener(oldID); err != nil {
+		slog.With(slog.Any("error", err)).Error("delete listener from storage failed")
+	}
+	slog.Info(fmt.Sprintf("chat_id: %v; перенесен в супергруппу %v", oldID, newID))
+}
+
+// метод обрабатывает изменение статуса бота в чате
+// пользователь заблокировал бота или бота удалили из группы - слушатель удаляется
+func (tg *TelegramApi) handleMyChatMember(u *tgbotapi.ChatMemberUpdated) {
+	switch u.NewChatMember.Status {
+	case "kicked", "left":
+		slog.With(
+			slog.Int64("chat_id", u.Chat.ID),
+			slog.String("status", u.NewChatMember.Status),
+		).Info("bot removed from chat, listener removed")
+		tg.removeListener(u.Chat.ID)
+	}
+}
@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifySendError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		failure chatFailure
+		newID   int64
+	}{
+		{
+			name:    "blocked",
+			err:     &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			failure: failureBlocked,
+		},
+		{
+			name:    "kicked",
+			err:     &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the supergroup chat"},
+			failure: failureKicked,
+		},
+		{
+			name:    "deactivated",
+			err:     &tgbotapi.Error{Code: 403, Message: "Forbidden: user is deactivated"},
+			failure: failureDeactivated,
+		},
+		{
+			name:    "chat not found",
+			err:     &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"},
+			failure: failureNotFound,
+		},
+		{
+			name: "migrated",
+			err: &tgbotapi.Error{
+				Code:               400,
+				Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+				ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -100123},
+			},
+			failure: failureMigrated,
+			newID:   -100123,
+		},
+		{
+			name:    "not modified",
+			err:     &tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same"},
+			failure: failureNotModified,
+		},
+		{
+			name:    "other bad request",
+			err:     &tgbotapi.Error{Code: 400, Message: "Bad Request: message is too long"},
+			failure: failureNone,
+		},
+		{
+			name:    "network error",
+			err:     errors.New("connection reset"),
+			failure: failureNone,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failure, newID := classifySendError(tc.err)
+			assert.Equal(t, tc.failure, failure)
+			assert.Equal(t, tc.newID, newID)
+		})
+	}
+}
+
+func TestMigrateListener(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	require.NoError(t, store.SaveListener(1, true))
+	require.NoError(t, store.SaveSubscriptions(1, []string{"/docs"}))
+	tg := &TelegramApi{
+		store:         store,
+		listeners:     map[int64]bool{1: true},
+		subscriptions: map[int64][]string{1: {"/docs"}},
+	}
+
+	tg.migrateListener(1, -100)
+	assert.Equal(t, map[int64]bool{-100: true}, tg.listeners)
+	assert.Equal(t, map[int64][]string{-100: {"/docs"}}, tg.subscriptions)
+	listeners, err := store.Listeners()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{-100: true}, listeners)
+	subscriptions, err := store.Subscriptions()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64][]string{-100: {"/docs"}}, subscriptions)
+
+	tg.removeListener(-100)
+	assert.Empty(t, tg.listeners)
+	listeners, err = store.Listeners()
+	require.NoError(t, err)
+	assert.Empty(t, listeners)
+}
+
+func TestRetarget(t *testing.T) {
+	photo := tgbotapi.NewPhoto(1, tgbotapi.FileBytes{Name: "a.jpg", Bytes: []byte("a")})
+	c, ok := retarget(photo, -100)
+	require.True(t, ok)
+	assert.Equal(t, int64(-100), c.(tgbotapi.PhotoConfig).ChatID)
+
+	doc := tgbotapi.NewDocument(1, tgbotapi.FileBytes{Name: "a.txt", Bytes: []byte("a")})
+	c, ok = retarget(doc, -100)
+	require.True(t, ok)
+	assert.Equal(t, int64(-100), c.(tgbotapi.DocumentConfig).ChatID)
+	// исходное сообщение не меняется
+	assert.Equal(t, int64(1), doc.ChatID)
+
+	c, ok = retarget(tgbotapi.NewMediaGroup(1, nil), -100)
+	require.True(t, ok)
+	assert.Equal(t, int64(-100), c.(tgbotapi.MediaGroupConfig).ChatID)
+
+	// правка текста превращается в новое сообщение
+	c, ok = retarget(tgbotapi.NewEditMessageText(1, 5, "страница"), -100)
+	require.True(t, ok)
+	msg := c.(tgbotapi.MessageConfig)
+	assert.Equal(t, int64(-100), msg.ChatID)
+	assert.Equal(t, "страница", msg.Text)
+
+	// кнопки старого сообщения в новом чате править нечего
+	c, ok = retarget(tgbotapi.NewEditMessageReplyMarkup(1, 5, tgbotapi.InlineKeyboardMarkup{}), -100)
+	assert.True(t, ok)
+	assert.Nil(t, c)
+
+	_, ok = retarget(tgbotapi.NewChatAction(1, tgbotapi.ChatTyping), -100)
+	assert.False(t, ok)
+}
+
+func TestMigratedMessageReported(t *testing.T) {
+	migrated := &tgbotapi.Error{
+		Code:               400,
+		Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+		ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -100},
+	}
+	sender := &fakeSender{errs: []error{migrated}}
+	tg := &TelegramApi{
+		store:     storage.NewMemoryStorage(),
+		listeners: map[int64]bool{1: true},
+	}
+	tg.queue = newOutbox(sender.send, tg.deadLetter, testQueueOptions())
+	tg.queue.start()
+
+	result := make(chan error, 1)
+	photo := tgbotapi.NewPhoto(1, tgbotapi.FileBytes{Name: "a.jpg", Bytes: []byte("a")})
+	require.NoError(t, tg.queue.pushReport(1, photo, func(err error) { result <- err }))
+	// переотправка в новый чат - успешная отправка
+	assert.NoError(t, <-result)
+	assert.Eventually(t, func() bool {
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+		return len(sender.calls) == 2
+	}, time.Second, 10*time.Millisecond)
+	tg.queue.close(time.Second)
+
+	assert.Equal(t, map[int64]bool{-100: true}, tg.listeners)
+	letters, err := tg.store.DeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestNotModifiedEditIsDelivered(t *testing.T) {
+	notModified := &tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified"}
+	sender := &fakeSender{errs: []error{notModified}}
+	tg := &TelegramApi{
+		store:     storage.NewMemoryStorage(),
+		listeners: map[int64]bool{1: true},
+	}
+	tg.queue = newOutbox(sender.send, tg.deadLetter, testQueueOptions())
+	tg.queue.start()
+
+	result := make(chan error, 1)
+	edit := tgbotapi.NewEditMessageText(1, 5, "страница")
+	require.NoError(t, tg.queue.pushReport(1, edit, func(err error) { result <- err }))
+	assert.NoError(t, <-result)
+	tg.queue.close(time.Second)
+
+	// повторное нажатие кнопки не попадает в недоставленные и не удаляет слушателя
+	assert.True(t, tg.listeners[1])
+	letters, err := tg.store.DeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
@@ -0,0 +1,286 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// как часто время доставки в чат записывается в хранилище
+const deliverySaveInterval = time.Minute
+
+// названия типов чатов для списка слушателей
+var chatTypes = map[string]string{
+	"private":    config.ChatPrivate,
+	"group":      config.ChatGroup,
+	"supergroup": config.ChatSupergroup,
+	"channel":    config.ChatChannel,
+}
+
+// команда /listeners: постраничный список слушателей
+func (tg *TelegramApi) listListeners(chatID int64) {
+	tg.showPage(chatID, 0, actionListeners, "", 0)
+}
+
+// команда /kick <chat_id>: удалить слушателя вместе с его подписками
+func (tg *TelegramApi) kick(chatID int64, arg string) {
+	target, ok := tg.parseListener(chatID, config.KickCmd, arg)
+	if !ok {
+		return
+	}
+	tg.removeListener(target)
+	slog.Info(fmt.Sprintf("chat_id: %v; администратор удалил слушателя %v", chatID, target))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespListenerKicked, target))
+}
+
+// команда /pause <chat_id>: приостановить уведомления в чат
+func (tg *TelegramApi) pause(chatID int64, arg string) {
+	target, ok := tg.parseListener(chatID, config.PauseCmd, arg)
+	if !ok {
+		return
+	}
+	if state, _ := tg.listenerState(target); !state {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespPausedAlready, target))
+		return
+	}
+	tg.changeStateListener(target, false)
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespListenerPaused, target))
+}
+
+// команда /resume <chat_id>: возобновить уведомления в чат
+func (tg *TelegramApi) resume(chatID int64, arg string) {
+	target, ok := tg.parseListener(chatID, config.ResumeCmd, arg)
+	if !ok {
+		return
+	}
+	if state, _ := tg.listenerState(target); state {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespResumedAlready, target))
+		return
+	}
+	tg.changeStateListener(target, true)
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespListenerResumed, target))
+}
+
+// метод разбирает chat_id слушателя из аргумента команды cmd
+// если chat_id не указан или такого слушателя нет, то администратору отправляется ответ
+func (tg *TelegramApi) parseListener(chatID int64, cmd, arg string) (int64, bool) {
+	target, err := strconv.ParseInt(strings.TrimSpace(arg), 10, 64)
+	if err != nil {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNeedChatID, config.ListenersCmd, cmd))
+		return 0, false
+	}
+	if _, err := tg.listenerState(target); err != nil {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespListenerNotFound, target))
+		return 0, false
+	}
+	return target, true
+}
+
+// метод возвращает страницу списка слушателей и общее количество слушателей
+func (tg *TelegramApi) listenersPage(offset int) (string, int, error) {
+	tg.mu.RLock()
+	chats := make([]int64, 0, len(tg.listeners))
+	for chatID := range tg.listeners {
+		chats = append(chats, chatID)
+	}
+	tg.mu.RUnlock()
+	if len(chats) == 0 {
+		return config.RespNoListeners, 0, nil
+	}
+	slices.Sort(chats)
+	if offset >= len(chats) {
+		offset = 0
+	}
+	page := chats[offset:min(offset+browsePageSize, len(chats))]
+	lines := make([]string, 0, len(page))
+	for _, chatID := range page {
+		lines = append(lines, tg.listenerLine(chatID))
+	}
+	return fmt.Sprintf(config.RespListeners, offset+1, offset+len(page), len(chats), strings.Join(lines, "\n")), len(chats), nil
+}
+
+// метод формирует строку списка слушателей
+func (tg *TelegramApi) listenerLine(chatID int64) string {
+	info := tg.chatInfo(chatID)
+	chatType, ok := chatTypes[info.Type]
+	if !ok {
+		chatType = config.ChatTypeUnknown
+	}
+
+	state := config.ListenerOff
+	if on, _ := tg.listenerState(chatID); on {
+		state = config.ListenerOn
+	}
+	delivered := config.NeverDelivered
+	if at := tg.lastDelivered(chatID); !at.IsZero() {
+		delivered = at.Format(time.DateTime)
+	}
+	return fmt.Sprintf(config.ListenerLine, chatTitle(tgbotapi.Chat{ID: chatID, Title: info.Title}), chatType, state, tg.chatFilters(chatID), delivered)
+}
+
+// метод возвращает папки, на которые подписан чат, и скрытые им папки
+func (tg *TelegramApi) chatFilters(chatID int64) string {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	filters := config.AllFolders
+	if folders := tg.subscriptions[chatID]; len(folders) > 0 {
+		filters = strings.Join(folders, ", ")
+	}
+	if mutes := tg.mutes[chatID]; len(mutes) > 0 {
+		filters += ", " + fmt.Sprintf(config.MutedFolders, strings.Join(mutes, ", "))
+	}
+	return filters
+}
+
+// метод запоминает название и тип чата, из которого пришло сообщение
+// сведения о чатах слушателей записываются в хранилище, только если они изменились
+func (tg *TelegramApi) rememberChat(chat *tgbotapi.Chat) {
+	if chat == nil {
+		return
+	}
+	title := chatName(*chat)
+	tg.muChats.Lock()
+	info := tg.chats[chat.ID]
+	if info.Title == title && info.Type == chat.Type {
+		tg.muChats.Unlock()
+		return
+	}
+	info.Title, info.Type = title, chat.Type
+	if tg.chats == nil {
+		tg.chats = make(map[int64]models.ChatInfo)
+	}
+	tg.chats[chat.ID] = info
+	tg.muChats.Unlock()
+	tg.saveChat(chat.ID, info)
+}
+
+// метод возвращает название и тип чата, если бот еще не видел чат - запрашивает его у Telegram
+// если чат недоступен, то название остается пустым и чат больше не запрашивается до перезапуска
+func (tg *TelegramApi) chatInfo(chatID int64) models.ChatInfo {
+	tg.muChats.Lock()
+	info := tg.chats[chatID]
+	known := info.Title != "" || tg.chatMisses[chatID]
+	tg.muChats.Unlock()
+	if known || tg.bot == nil {
+		return info
+	}
+	chat, err := tg.bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		slog.With(slog.Int64("chat_id", chatID), slog.Any("error", err)).Warn("get chat failed")
+		tg.muChats.Lock()
+		if tg.chatMisses == nil {
+			tg.chatMisses = make(map[int64]bool)
+		}
+		tg.chatMisses[chatID] = true
+		tg.muChats.Unlock()
+		return info
+	}
+	tg.rememberChat(&chat)
+	tg.muChats.Lock()
+	defer tg.muChats.Unlock()
+	return tg.chats[chatID]
+}
+
+// метод запоминает время доставки сообщения в чат, ошибки отправки не учитываются
+// метод вызывается обработчиком очереди, поэтому время только запоминается,
+// а в хранилище его раз в deliverySaveInterval записывает saveDeliveries
+func (tg *TelegramApi) markDelivered(chatID int64, err error) {
+	if err != nil {
+		return
+	}
+	tg.muChats.Lock()
+	defer tg.muChats.Unlock()
+	if tg.chats == nil {
+		tg.chats = make(map[int64]models.ChatInfo)
+	}
+	info := tg.chats[chatID]
+	info.Delivered = time.Now()
+	tg.chats[chatID] = info
+}
+
+// метод периодически записывает время доставки в хранилище, пока не закрыт stopCh
+func (tg *TelegramApi) persistDeliveries(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(deliverySaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tg.saveDeliveries()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// метод записывает в хранилище время доставки, которое изменилось с прошлой записи
+func (tg *TelegramApi) saveDeliveries() {
+	changed := make(map[int64]models.ChatInfo)
+	tg.muChats.Lock()
+	if tg.deliverySaved == nil {
+		tg.deliverySaved = make(map[int64]time.Time)
+	}
+	for chatID, info := range tg.chats {
+		if info.Delivered.After(tg.deliverySaved[chatID]) {
+			changed[chatID] = info
+			tg.deliverySaved[chatID] = info.Delivered
+		}
+	}
+	tg.muChats.Unlock()
+	for chatID, info := range changed {
+		tg.saveChat(chatID, info)
+	}
+}
+
+// метод возвращает время последней доставки сообщения в чат, нулевое время - сообщения в чат не доставлялись
+func (tg *TelegramApi) lastDelivered(chatID int64) time.Time {
+	tg.muChats.Lock()
+	defer tg.muChats.Unlock()
+	return tg.chats[chatID].Delivered
+}
+
+// метод записывает сведения о чате в хранилище, если чат - слушатель
+func (tg *TelegramApi) saveChat(chatID int64, info models.ChatInfo) {
+	tg.mu.RLock()
+	_, ok := tg.listeners[chatID]
+	tg.mu.RUnlock()
+	if !ok {
+		return
+	}
+	if err := tg.store.SaveChat(chatID, info); err != nil {
+		slog.With(slog.Any("error", err)).Error("save chat to storage failed")
+	}
+}
+
+// уведомление о файле, доставленное в чат
+type deliveredNotice struct {
+	at   time.Time // время доставки
+	name string    // имя файла
+}
+
+// метод запоминает уведомление о файле name, доставленное в чат, ошибки отправки не учитываются
+func (tg *TelegramApi) markNotice(chatID int64, name string, err error) {
+	if err != nil {
+		return
+	}
+	tg.muChats.Lock()
+	defer tg.muChats.Unlock()
+	if tg.notices == nil {
+		tg.notices = make(map[int64]deliveredNotice)
+	}
+	tg.notices[chatID] = deliveredNotice{at: time.Now(), name: name}
+}
+
+// метод возвращает последнее доставленное в чат уведомление, false - с момента запуска уведомлений не было
+func (tg *TelegramApi) lastNotice(chatID int64) (deliveredNotice, bool) {
+	tg.muChats.Lock()
+	defer tg.muChats.Unlock()
+	n, ok := tg.notices[chatID]
+	return n, ok
+}
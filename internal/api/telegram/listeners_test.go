@@ -0,0 +1,173 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenersPage(t *testing.T) {
+	tg := &TelegramApi{
+		store:         storage.NewMemoryStorage(),
+		listeners:     map[int64]bool{-100: true, 5: false},
+		subscriptions: map[int64][]string{-100: {"/Общее", "/Отчеты"}},
+		mutes:         map[int64][]string{5: {"/Фото"}},
+	}
+	tg.rememberChat(&tgbotapi.Chat{ID: -100, Type: "supergroup", Title: "Отдел"})
+	tg.rememberChat(&tgbotapi.Chat{ID: 5, Type: "private", UserName: "user"})
+	tg.markDelivered(-100, nil)
+	// ошибка отправки не считается доставкой
+	tg.markDelivered(5, assert.AnError)
+
+	text, total, err := tg.listenersPage(0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "Слушатели, 1-2 из 2:\n"+
+		"Отдел (-100), супергруппа, читает\n    фильтры: /Общее, /Отчеты; доставлено: "+tg.lastDelivered(-100).Format(time.DateTime)+"\n"+
+		"@user (5), личный чат, на паузе\n    фильтры: все папки, скрыты /Фото; доставлено: еще не было", text)
+
+	// время доставки записывается в хранилище не из очереди, а в фоне
+	chats, err := tg.store.Chats()
+	require.NoError(t, err)
+	assert.True(t, chats[-100].Delivered.IsZero())
+	tg.saveDeliveries()
+
+	// название и время доставки переживают перезапуск
+	restarted := &TelegramApi{store: tg.store}
+	require.NoError(t, restarted.loadState())
+	assert.Equal(t, tg.chatInfo(-100), restarted.chatInfo(-100))
+	assert.Equal(t, tg.lastDelivered(-100), restarted.lastDelivered(-100))
+
+	// название чата, который стал слушателем, записывается в хранилище
+	tg.rememberChat(&tgbotapi.Chat{ID: 8, Type: "group", Title: "Склад"})
+	tg.addListener(8)
+	chats, err = tg.store.Chats()
+	require.NoError(t, err)
+	assert.Equal(t, "Склад", chats[8].Title)
+
+	// о неизвестном чате без бота известен только chat_id
+	tg.listeners[7] = true
+	line := tg.listenerLine(7)
+	assert.Equal(t, "7, тип неизвестен, читает\n    фильтры: все папки; доставлено: еще не было", line)
+
+	tg.listeners = map[int64]bool{}
+	text, total, err = tg.listenersPage(0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Equal(t, "Слушателей нет", text)
+}
+
+func TestControlListener(t *testing.T) {
+	sender := &recordSender{texts: make(map[int64][]string)}
+	tg := &TelegramApi{
+		store:         storage.NewMemoryStorage(),
+		listeners:     map[int64]bool{-100: true},
+		subscriptions: map[int64][]string{-100: {"/Общее"}},
+		mutes:         map[int64][]string{},
+		uploadFolders: map[int64]string{},
+	}
+	tg.queue = newOutbox(sender.send, noFail, testQueueOptions())
+	tg.queue.start()
+
+	tg.pause(1, "-100")
+	tg.pause(1, "-100")
+	assert.False(t, tg.listeners[-100])
+	tg.resume(1, "-100")
+	assert.True(t, tg.listeners[-100])
+	tg.kick(1, "-100")
+	tg.kick(1, "-100")
+	tg.kick(1, "abc")
+	tg.queue.close(testQueueOptions().backoffMax * 10)
+
+	assert.NotContains(t, tg.listeners, int64(-100))
+	assert.NotContains(t, tg.subscriptions, int64(-100))
+	assert.Equal(t, []string{
+		"Уведомления в чат -100 приостановлены",
+		"Уведомления в чат -100 уже приостановлены",
+		"Уведомления в чат -100 возобновлены",
+		"Чат -100 удален из слушателей",
+		"Чат -100 не найден среди слушателей",
+		"Укажите chat_id из списка /listeners, например: /kick -1001234567890",
+	}, sender.texts[1])
+	// ответы администратору учитываются как доставка
+	assert.False(t, tg.lastDelivered(1).IsZero())
+}
+
+func TestChatInfoCached(t *testing.T) {
+	var getChat atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "getMe":
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot"}}`)
+		case "getChat":
+			getChat.Add(1)
+			if r.FormValue("chat_id") == "-100" {
+				fmt.Fprint(w, `{"ok":true,"result":{"id":-100,"type":"supergroup","title":"Отдел"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+		}
+	}))
+	defer srv.Close()
+	bot, err := tgbotapi.NewBotAPIWithClient("token", srv.URL+"/bot%s/%s", srv.Client())
+	require.NoError(t, err)
+
+	tg := &TelegramApi{
+		bot:       bot,
+		store:     storage.NewMemoryStorage(),
+		listeners: map[int64]bool{-100: true, 7: true},
+	}
+	for range 2 {
+		text, _, err := tg.listenersPage(0)
+		require.NoError(t, err)
+		assert.Contains(t, text, "Отдел (-100), супергруппа")
+		assert.Contains(t, text, "7, тип неизвестен")
+	}
+	// каждый чат запрашивается один раз, недоступный чат тоже
+	assert.Equal(t, int32(2), getChat.Load())
+
+	// после перезапуска название берется из хранилища
+	restarted := &TelegramApi{bot: bot, store: tg.store, listeners: map[int64]bool{-100: true}}
+	require.NoError(t, restarted.loadState())
+	assert.Equal(t, "Отдел", restarted.chatInfo(-100).Title)
+	assert.Equal(t, int32(2), getChat.Load())
+}
+
+func TestDeleteAllListeners(t *testing.T) {
+	sender := &recordSender{texts: make(map[int64][]string)}
+	tg := &TelegramApi{
+		store:         storage.NewMemoryStorage(),
+		listeners:     map[int64]bool{1: true, -100: true, 5: false},
+		subscriptions: map[int64][]string{-100: {"/Общее"}},
+		mutes:         map[int64][]string{},
+		uploadFolders: map[int64]string{},
+	}
+	tg.queue = newOutbox(sender.send, noFail, testQueueOptions())
+	tg.queue.start()
+	tg.rememberChat(&tgbotapi.Chat{ID: -100, Type: "supergroup", Title: "Отдел"})
+	tg.markDelivered(-100, nil)
+	tg.markNotice(-100, "отчет.docx", nil)
+
+	tg.deleteAllListeners(1)
+	tg.queue.close(testQueueOptions().backoffMax * 10)
+
+	// чат администратора остается, у удаленных чатов не остается сведений
+	assert.Equal(t, map[int64]bool{1: true}, tg.listeners)
+	assert.Empty(t, tg.subscriptions)
+	assert.True(t, tg.lastDelivered(-100).IsZero())
+	_, ok := tg.lastNotice(-100)
+	assert.False(t, ok)
+	chats, err := tg.store.Chats()
+	require.NoError(t, err)
+	assert.NotContains(t, chats, int64(-100))
+	assert.Len(t, sender.texts[1], 1)
+}
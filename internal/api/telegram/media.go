@@ -0,0 +1,178 @@
+package telegram
+
+import (
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxCaptionLength = 1024 // максимальная длина подписи к фото или документу
+	mediaGroupLimit  = 10   // максимальное количество фото в альбоме
+)
+
+// вложение к уведомлению о файле
+type attachment struct {
+	photo bool               // true - отправляется фото, false - документ
+	file  tgbotapi.FileBytes // содержимое, хранится в памяти, чтобы повторять отправку и слать в несколько чатов
+}
+
+// метод скачивает вложения для загруженных файлов, ключ - путь до файла
+// изображения до лимита отправляются целиком, больше лимита - превью, документы до лимита - файлом
+// вложения хранятся в памяти до отправки, поэтому за один вызов скачивается не больше mediaBatch байт,
+// файлы сверх этого объема отправляются текстом
+func (tg *TelegramApi) loadAttachments(data models.UpdateInfoSlice) map[string]*attachment {
+	attachments := make(map[string]*attachment)
+	if tg.yandexApi == nil || tg.textOnly {
+		return attachments
+	}
+	left := tg.mediaBatch
+	for _, elem := range data {
+		// лимит файла не больше оставшегося объема
+		limit := min(tg.mediaMaxSize, left)
+		if limit <= 0 {
+			break
+		}
+		if elem.Event != models.EventCreated || elem.Type != "file" {
+			continue
+		}
+		if _, ok := attachments[elem.Path]; ok {
+			continue
+		}
+		var (
+			content []byte
+			err     error
+		)
+		switch {
+		case elem.Size > 0 && elem.Size <= limit:
+			content, err = tg.yandexApi.Download(elem.Path, limit)
+		case elem.IsImage() && elem.Preview != "":
+			content, err = tg.yandexApi.Preview(elem.Preview, limit)
+		default:
+			// файл больше лимита, будет текстовое уведомление
+			continue
+		}
+		if err != nil {
+			slog.With(slog.String("path", elem.Path), slog.Any("error", err)).Warn("load attachment failed")
+			continue
+		}
+		left -= int64(len(content))
+		attachments[elem.Path] = &attachment{
+			photo: elem.IsImage(),
+			file:  tgbotapi.FileBytes{Name: elem.Title, Bytes: content},
+		}
+	}
+	return attachments
+}
+
+// функция возвращает файлы из data, которые получит хотя бы один чат, в порядке data
+func receivedUpdates(data models.UpdateInfoSlice, updates map[int64]models.UpdateInfoSlice) models.UpdateInfoSlice {
+	needed := make(map[string]bool)
+	for _, filtered := range updates {
+		for _, elem := range filtered {
+			needed[elem.Path] = true
+		}
+	}
+	var received models.UpdateInfoSlice
+	for _, elem := range data {
+		if needed[elem.Path] {
+			received = append(received, elem)
+		}
+	}
+	return received
+}
+
+// фото для отправки вместе с кнопками
+type photoItem struct {
+	name     string // имя файла
+	media    tgbotapi.InputMediaPhoto
+	keyboard *tgbotapi.InlineKeyboardMarkup
+}
+
+// метод отправляет уведомления в чат: файлы с вложениями - фото и документами с подписью,
+// остальные - текстом, под каждым уведомлением кнопки действий с файлом
+func (tg *TelegramApi) sendUpdates(chatID int64, data models.UpdateInfoSlice, attachments map[string]*attachment) {
+	var (
+		texts  models.UpdateInfoSlice
+		photos []photoItem
+	)
+	for index, elem := range data {
+		a, ok := attachments[elem.Path]
+		if !ok {
+			texts = append(texts, elem)
+			continue
+		}
+		caption, err := tg.renderer.RenderItem(1, elem)
+		if err != nil || utf8.RuneCountInString(caption) > maxCaptionLength {
+			// подпись не помещается, уведомление уходит текстом
+			texts = append(texts, elem)
+			continue
+		}
+		keyboard := tg.keyboard(index, data[index:index+1], false)
+		if a.photo {
+			photo := tgbotapi.NewInputMediaPhoto(a.file)
+			photo.Caption = caption
+			photo.ParseMode = tg.renderer.ParseMode()
+			photos = append(photos, photoItem{name: elem.Title, media: photo, keyboard: keyboard})
+			continue
+		}
+		doc := tgbotapi.NewDocument(chatID, a.file)
+		doc.Caption = caption
+		doc.ParseMode = tg.renderer.ParseMode()
+		if keyboard != nil {
+			doc.ReplyMarkup = *keyboard
+		}
+		tg.sendFileNotice(chatID, elem.Title, doc)
+	}
+	tg.sendPhotos(chatID, photos)
+
+	msgs, err := tg.renderer.Render(texts)
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	for _, msg := range msgs {
+		tg.sendNotice(chatID, msg.Items[len(msg.Items)-1].Title, msg.Text, tg.keyboard(msg.Offset, msg.Items, len(texts) > 1))
+	}
+}
+
+// метод отправляет фото альбомами, одно фото отправляется обычным сообщением
+// у альбома не может быть кнопок, поэтому кнопки есть только у одиночных фото
+func (tg *TelegramApi) sendPhotos(chatID int64, photos []photoItem) {
+	for len(photos) > 0 {
+		n := min(len(photos), mediaGroupLimit)
+		group := photos[:n]
+		photos = photos[n:]
+		if len(group) == 1 {
+			photo := tgbotapi.NewPhoto(chatID, group[0].media.Media)
+			photo.Caption = group[0].media.Caption
+			photo.ParseMode = group[0].media.ParseMode
+			if group[0].keyboard != nil {
+				photo.ReplyMarkup = *group[0].keyboard
+			}
+			tg.sendFileNotice(chatID, group[0].name, photo)
+			continue
+		}
+		media := make([]interface{}, 0, len(group))
+		for _, item := range group {
+			media = append(media, item.media)
+		}
+		tg.sendFileNotice(chatID, group[len(group)-1].name, tgbotapi.NewMediaGroup(chatID, media))
+	}
+}
+
+// метод отправляет сообщение в Telegram
+// альбом отправляется отдельным методом, так как в ответ приходит массив сообщений
+func (tg *TelegramApi) deliver(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	group, ok := c.(tgbotapi.MediaGroupConfig)
+	if !ok {
+		return tg.bot.Send(c)
+	}
+	msgs, err := tg.bot.SendMediaGroup(group)
+	if err != nil || len(msgs) == 0 {
+		return tgbotapi.Message{}, err
+	}
+	return msgs[0], nil
+}
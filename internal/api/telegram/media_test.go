@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// заглушка Диска, которая отдает файлы нужного размера и запоминает скачанные
+type downloadYandexApi struct {
+	yandexdisk.YandexDiskApi
+	sizes      map[string]int
+	downloaded []string
+}
+
+func (d *downloadYandexApi) Download(filePath string, limit int64) ([]byte, error) {
+	if int64(d.sizes[filePath]) > limit {
+		return nil, errorApi.ErrFileTooLarge
+	}
+	d.downloaded = append(d.downloaded, filePath)
+	return make([]byte, d.sizes[filePath]), nil
+}
+
+func TestLoadAttachments(t *testing.T) {
+	upload := func(name string, size int64) *models.UpdateInfo {
+		return &models.UpdateInfo{Title: name, Path: "disk:/" + name, Type: "file", Size: size}
+	}
+	a, b, c := upload("a.txt", 40), upload("b.txt", 40), upload("c.txt", 10)
+	data := models.UpdateInfoSlice{a, b, c}
+
+	// скачиваются только файлы, которые получит хотя бы один чат
+	received := receivedUpdates(data, map[int64]models.UpdateInfoSlice{
+		1: {a},
+		2: {a, c},
+	})
+	assert.Equal(t, models.UpdateInfoSlice{a, c}, received)
+
+	api := &downloadYandexApi{sizes: map[string]int{a.Path: 40, b.Path: 40, c.Path: 10}}
+	tg := &TelegramApi{yandexApi: api, mediaMaxSize: 50, mediaBatch: 60}
+	attachments := tg.loadAttachments(data)
+	// на b объема не хватает, он уйдет текстом, а меньший c еще помещается
+	assert.Equal(t, []string{a.Path, c.Path}, api.downloaded)
+	assert.Contains(t, attachments, a.Path)
+	assert.NotContains(t, attachments, b.Path)
+	assert.Contains(t, attachments, c.Path)
+}
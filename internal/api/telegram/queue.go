@@ -0,0 +1,383 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// функция отправки сообщения в Telegram, в боте - BotAPI.Send
+type sendFunc func(c tgbotapi.Chattable) (tgbotapi.Message, error)
+
+// функция, которая вызывается, когда сообщение не удалось доставить
+// true - сообщение переотправлено (например, в новый chat_id), отправка считается успешной
+type failFunc func(m outMsg, err error, attempts int) bool
+
+// параметры очереди исходящих сообщений
+type queueOptions struct {
+	size         int           // размер буфера очереди
+	globalRate   int           // сообщений в секунду во все чаты
+	chatInterval time.Duration // минимальный интервал между сообщениями в один чат
+	maxAttempts  int           // попыток отправки одного сообщения
+	backoffBase  time.Duration // начальная пауза при ошибке сервера, удваивается с каждой попыткой
+	backoffMax   time.Duration // максимальная пауза при ошибке сервера
+}
+
+// исходящее сообщение
+type outMsg struct {
+	chatID int64
+	msg    tgbotapi.Chattable
+	done   func(err error) // вызывается, когда отправка завершена: nil - доставлено, иначе последняя ошибка
+}
+
+// метод сообщает результат отправки, если он кому-то нужен
+func (m outMsg) finish(err error) {
+	if m.done != nil {
+		m.done(err)
+	}
+}
+
+// текст сообщения для журнала недоставленных
+func (m outMsg) text() string {
+	switch c := m.msg.(type) {
+	case tgbotapi.MessageConfig:
+		return c.Text
+	case tgbotapi.PhotoConfig:
+		return c.Caption
+	case tgbotapi.DocumentConfig:
+		return c.Caption
+	case tgbotapi.EditMessageTextConfig:
+		return c.Text
+	}
+	return fmt.Sprintf("%T", m.msg)
+}
+
+// очередь исходящих сообщений между ботом и Telegram Bot API
+// сообщения отправляет один обработчик, но у каждого чата своя очередь: порядок сообщений в чате сохраняется,
+// а чат, который ждет интервал между сообщениями или паузу после ошибки, не задерживает другие чаты
+type outbox struct {
+	send    sendFunc
+	onFail  failFunc
+	opts    queueOptions
+	limiter *limiter
+
+	jobs   chan outMsg
+	mu     sync.RWMutex // мьютекс для closed, чтобы не писать в закрытый канал
+	closed bool
+	ctx    context.Context // отменяется, если оставшиеся сообщения не успели отправиться при остановке
+	cancel context.CancelFunc
+	done   chan struct{} // закрывается, когда обработчик завершился
+
+	// очереди чатов, используются только обработчиком, поэтому без мьютекса
+	chats   map[int64]*chatQueue // чаты, в которые есть что отправить
+	order   []int64              // очередность чатов: чат, в который только что отправили, уходит в конец
+	pending int                  // сообщений во всех очередях чатов
+}
+
+// очередь сообщений одного чата
+type chatQueue struct {
+	msgs    []outMsg
+	attempt int       // сколько раз уже отправлялось первое сообщение
+	retryAt time.Time // до этого времени чат на паузе после ошибки
+}
+
+// конструктор очереди исходящих сообщений
+func newOutbox(send sendFunc, onFail failFunc, opts queueOptions) *outbox {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &outbox{
+		send:    send,
+		onFail:  onFail,
+		opts:    opts,
+		limiter: newLimiter(opts.globalRate, opts.chatInterval),
+		jobs:    make(chan outMsg, opts.size),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		chats:   make(map[int64]*chatQueue),
+	}
+}
+
+// метод запускает обработчик очереди
+func (q *outbox) start() {
+	go q.run()
+}
+
+// метод ставит сообщение в очередь, если очередь заполнена - ждет
+func (q *outbox) push(chatID int64, msg tgbotapi.Chattable) error {
+	return q.pushReport(chatID, msg, nil)
+}
+
+// метод ставит сообщение в очередь, done вызывается после отправки
+// если сообщение не поставлено в очередь, то done не вызывается
+func (q *outbox) pushReport(chatID int64, msg tgbotapi.Chattable, done func(err error)) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errorApi.ErrQueueClosed
+	}
+	select {
+	case q.jobs <- outMsg{chatID: chatID, msg: msg, done: done}:
+		return nil
+	case <-q.ctx.Done():
+		return errorApi.ErrQueueClosed
+	}
+}
+
+// метод закрывает очередь и ждет отправки оставшихся сообщений не дольше timeout
+func (q *outbox) close(timeout time.Duration) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-time.After(timeout):
+		slog.Warn("message queue drain timeout")
+		q.cancel()
+		<-q.done
+	}
+	q.cancel()
+}
+
+// обработчик очереди: принимает новые сообщения и отправляет первое сообщение чата, который готов раньше всех
+// работает, пока очередь не закрыта и все сообщения не отправлены
+func (q *outbox) run() {
+	defer close(q.done)
+	jobs := q.jobs
+	for jobs != nil || q.pending > 0 {
+		if q.ctx.Err() != nil {
+			q.dropAll()
+			return
+		}
+		chatID, at, ok := q.next()
+		if ok && !at.After(time.Now()) {
+			q.deliver(chatID)
+			continue
+		}
+		// новые сообщения принимаются, пока в очередях чатов есть место
+		var intake <-chan outMsg
+		if q.pending < max(q.opts.size, 1) {
+			intake = jobs
+		}
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if ok {
+			timer = time.NewTimer(time.Until(at))
+			ready = timer.C
+		}
+		select {
+		case m, open := <-intake:
+			if !open {
+				jobs = nil
+			} else {
+				q.enqueue(m)
+			}
+		case <-ready:
+		case <-q.ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// метод добавляет сообщение в очередь его чата
+func (q *outbox) enqueue(m outMsg) {
+	cq, ok := q.chats[m.chatID]
+	if !ok {
+		cq = &chatQueue{}
+		q.chats[m.chatID] = cq
+		q.order = append(q.order, m.chatID)
+	}
+	cq.msgs = append(cq.msgs, m)
+	q.pending++
+}
+
+// метод возвращает чат, в который можно отправить сообщение раньше других, и время, когда это можно сделать
+// при одинаковом времени выбирается чат, который дольше ждет своей очереди
+func (q *outbox) next() (int64, time.Time, bool) {
+	var (
+		chatID int64
+		at     time.Time
+		found  bool
+	)
+	for _, id := range q.order {
+		ready := q.limiter.chatReady(id)
+		if retryAt := q.chats[id].retryAt; retryAt.After(ready) {
+			ready = retryAt
+		}
+		if !found || ready.Before(at) {
+			chatID, at, found = id, ready, true
+		}
+	}
+	if !found {
+		return 0, time.Time{}, false
+	}
+	// общий лимит на все чаты
+	if global := q.limiter.globalReady(); global.After(at) {
+		at = global
+	}
+	return chatID, at, true
+}
+
+// метод отправляет первое сообщение чата
+// при временной ошибке сообщение остается первым, а на паузу встает только этот чат
+func (q *outbox) deliver(chatID int64) {
+	cq := q.chats[chatID]
+	m := cq.msgs[0]
+	cq.attempt++
+	q.limiter.mark(chatID, time.Now())
+	_, err := q.send(m.msg)
+	if err == nil {
+		q.pop(chatID)
+		m.finish(nil)
+		return
+	}
+	// 429 приходит на конкретный чат, поэтому пауза retry_after тоже только для него
+	attempt := cq.attempt
+	delay, retry := q.retryDelay(err, attempt)
+	if !retry || attempt >= q.opts.maxAttempts {
+		q.pop(chatID)
+		if q.onFail(m, err, attempt) {
+			m.finish(nil)
+			return
+		}
+		m.finish(err)
+		return
+	}
+	slog.With(
+		slog.Int64("chat_id", m.chatID),
+		slog.Int("attempt", attempt),
+		slog.String("retry_in", delay.String()),
+		slog.Any("error", err),
+	).Warn("send message failed, retrying")
+	cq.retryAt = time.Now().Add(delay)
+}
+
+// метод убирает первое сообщение чата, чат с оставшимися сообщениями уходит в конец очередности
+func (q *outbox) pop(chatID int64) {
+	cq := q.chats[chatID]
+	cq.msgs = cq.msgs[1:]
+	cq.attempt = 0
+	cq.retryAt = time.Time{}
+	q.pending--
+	if index := slices.Index(q.order, chatID); index != -1 {
+		q.order = slices.Delete(q.order, index, index+1)
+	}
+	if len(cq.msgs) == 0 {
+		delete(q.chats, chatID)
+		return
+	}
+	q.order = append(q.order, chatID)
+}
+
+// метод сообщает об отмене всех неотправленных сообщений при остановке
+func (q *outbox) dropAll() {
+	// очередь уже закрыта, поэтому чтение из канала завершится
+	for m := range q.jobs {
+		q.enqueue(m)
+	}
+	for _, chatID := range q.order {
+		for _, m := range q.chats[chatID].msgs {
+			slog.With(slog.Int64("chat_id", m.chatID)).Warn("message dropped on shutdown")
+			m.finish(errorApi.ErrQueueClosed)
+		}
+	}
+	q.chats = make(map[int64]*chatQueue)
+	q.order = nil
+	q.pending = 0
+}
+
+// метод возвращает паузу перед следующей попыткой и нужно ли повторять отправку
+// 429 - пауза, которую вернул Telegram в retry_after
+// 5xx и сетевые ошибки - экспоненциальная пауза
+// остальные ошибки API (400, 403 и т.д.) повторять бессмысленно
+func (q *outbox) retryDelay(err error, attempt int) (time.Duration, bool) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return q.backoff(attempt), true
+	}
+	switch {
+	case tgErr.Code == http.StatusTooManyRequests && tgErr.RetryAfter > 0:
+		return time.Duration(tgErr.RetryAfter) * time.Second, true
+	case tgErr.Code == http.StatusTooManyRequests, tgErr.Code >= http.StatusInternalServerError:
+		return q.backoff(attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// экспоненциальная пауза для попытки attempt: base, 2*base, 4*base ... но не больше max
+func (q *outbox) backoff(attempt int) time.Duration {
+	delay := q.opts.backoffBase
+	for i := 1; i < attempt && delay < q.opts.backoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, q.opts.backoffMax)
+}
+
+// ограничитель частоты отправки: общий на все чаты и отдельный для каждого чата
+// используется только обработчиком очереди, поэтому без мьютекса
+type limiter struct {
+	global  time.Duration       // минимальный интервал между любыми сообщениями
+	perChat time.Duration       // минимальный интервал между сообщениями в один чат
+	last    time.Time           // время последней отправки
+	chats   map[int64]time.Time // время последней отправки в чат
+}
+
+func newLimiter(rate int, perChat time.Duration) *limiter {
+	l := &limiter{
+		perChat: perChat,
+		chats:   make(map[int64]time.Time),
+	}
+	if rate > 0 {
+		l.global = time.Second / time.Duration(rate)
+	}
+	return l
+}
+
+// метод возвращает время, с которого можно отправить сообщение в чат chatID без учета общего лимита
+func (l *limiter) chatReady(chatID int64) time.Time {
+	last, ok := l.chats[chatID]
+	if !ok {
+		return time.Time{}
+	}
+	return last.Add(l.perChat)
+}
+
+// метод возвращает время, с которого можно отправить любое сообщение
+func (l *limiter) globalReady() time.Time {
+	return l.last.Add(l.global)
+}
+
+// метод запоминает отправку в чат chatID
+func (l *limiter) mark(chatID int64, now time.Time) {
+	l.last = now
+	l.chats[chatID] = now
+	l.cleanup(now)
+}
+
+// метод удаляет чаты, интервал которых уже прошел, чтобы мапа не росла бесконечно
+func (l *limiter) cleanup(now time.Time) {
+	if len(l.chats) < 1000 {
+		return
+	}
+	for chatID, last := range l.chats {
+		if now.Sub(last) >= l.perChat {
+			delete(l.chats, chatID)
+		}
+	}
+}
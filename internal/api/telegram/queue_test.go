@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// заглушка отправки, возвращает ошибки из errs по очереди, затем успех
+type fakeSender struct {
+	mu    sync.Mutex
+	errs  []error
+	calls []time.Time
+	chats []int64 // чаты, в которые шла отправка, в порядке вызовов
+}
+
+func (f *fakeSender) send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, time.Now())
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		f.chats = append(f.chats, msg.ChatID)
+	}
+	if len(f.errs) == 0 {
+		return tgbotapi.Message{}, nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return tgbotapi.Message{}, err
+}
+
+// обработчик недоставленных сообщений, который ничего не делает
+func noFail(outMsg, error, int) bool {
+	return false
+}
+
+func testQueueOptions() queueOptions {
+	return queueOptions{
+		size:         10,
+		globalRate:   1000,
+		chatInterval: time.Millisecond,
+		maxAttempts:  3,
+		backoffBase:  time.Millisecond,
+		backoffMax:   5 * time.Millisecond,
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	serverErr := &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}
+	badRequest := &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}
+	netErr := errors.New("connection reset")
+
+	testCases := []struct {
+		name      string
+		errs      []error
+		calls     int
+		failed    bool
+		attempts  int
+		lastError error
+	}{
+		{
+			name:  "success",
+			calls: 1,
+		},
+		{
+			name:  "server error then success",
+			errs:  []error{serverErr, netErr},
+			calls: 3,
+		},
+		{
+			name:      "permanent error",
+			errs:      []error{badRequest},
+			calls:     1,
+			failed:    true,
+			attempts:  1,
+			lastError: badRequest,
+		},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{serverErr, serverErr, serverErr, serverErr},
+			calls:     3,
+			failed:    true,
+			attempts:  3,
+			lastError: serverErr,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &fakeSender{errs: tc.errs}
+			var (
+				failed   bool
+				attempts int
+				lastErr  error
+			)
+			q := newOutbox(sender.send, func(m outMsg, err error, n int) bool {
+				failed, attempts, lastErr = true, n, err
+				return false
+			}, testQueueOptions())
+			q.start()
+			require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "text")))
+			q.close(time.Second)
+
+			assert.Len(t, sender.calls, tc.calls)
+			assert.Equal(t, tc.failed, failed)
+			assert.Equal(t, tc.attempts, attempts)
+			assert.Equal(t, tc.lastError, lastErr)
+		})
+	}
+}
+
+func TestOutboxRetryAfter(t *testing.T) {
+	tooMany := &tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1},
+	}
+	sender := &fakeSender{errs: []error{tooMany}}
+	q := newOutbox(sender.send, func(outMsg, error, int) bool {
+		t.Error("message must be delivered")
+		return false
+	}, testQueueOptions())
+	q.start()
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "text")))
+	q.close(5 * time.Second)
+
+	require.Len(t, sender.calls, 2)
+	assert.GreaterOrEqual(t, sender.calls[1].Sub(sender.calls[0]), time.Second)
+}
+
+func TestOutboxChatInterval(t *testing.T) {
+	sender := &fakeSender{}
+	opts := testQueueOptions()
+	opts.chatInterval = 50 * time.Millisecond
+	q := newOutbox(sender.send, noFail, opts)
+	q.start()
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "first")))
+	require.NoError(t, q.push(2, tgbotapi.NewMessage(2, "other chat")))
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "second")))
+	q.close(time.Second)
+
+	require.Len(t, sender.calls, 3)
+	// другой чат не ждет интервал первого
+	assert.Less(t, sender.calls[1].Sub(sender.calls[0]), opts.chatInterval)
+	assert.GreaterOrEqual(t, sender.calls[2].Sub(sender.calls[0]), opts.chatInterval)
+
+	assert.ErrorIs(t, q.push(1, tgbotapi.NewMessage(1, "closed")), errorApi.ErrQueueClosed)
+}
+
+func TestOutboxChatIntervalDoesNotBlockOthers(t *testing.T) {
+	sender := &fakeSender{}
+	opts := testQueueOptions()
+	opts.chatInterval = 100 * time.Millisecond
+	q := newOutbox(sender.send, noFail, opts)
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "first")))
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "second")))
+	require.NoError(t, q.push(2, tgbotapi.NewMessage(2, "other chat")))
+	q.start()
+	q.close(time.Second)
+
+	require.Len(t, sender.calls, 3)
+	// сообщение в другой чат не ждет, пока истечет интервал первого чата
+	assert.Equal(t, []int64{1, 2, 1}, sender.chats)
+	assert.Less(t, sender.calls[1].Sub(sender.calls[0]), opts.chatInterval)
+	assert.GreaterOrEqual(t, sender.calls[2].Sub(sender.calls[0]), opts.chatInterval)
+}
+
+func TestOutboxRetryAfterPausesOnlyChat(t *testing.T) {
+	tooMany := &tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1},
+	}
+	sender := &fakeSender{errs: []error{tooMany}}
+	q := newOutbox(sender.send, func(outMsg, error, int) bool {
+		t.Error("message must be delivered")
+		return false
+	}, testQueueOptions())
+	require.NoError(t, q.push(1, tgbotapi.NewMessage(1, "limited")))
+	require.NoError(t, q.push(2, tgbotapi.NewMessage(2, "other chat")))
+	q.start()
+	q.close(5 * time.Second)
+
+	require.Len(t, sender.calls, 3)
+	assert.Equal(t, []int64{1, 2, 1}, sender.chats)
+	assert.Less(t, sender.calls[1].Sub(sender.calls[0]), time.Second)
+	assert.GreaterOrEqual(t, sender.calls[2].Sub(sender.calls[0]), time.Second)
+}
+
+func TestOutboxDropOnShutdown(t *testing.T) {
+	tooMany := &tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 10},
+	}
+	sender := &fakeSender{errs: []error{tooMany}}
+	q := newOutbox(sender.send, noFail, testQueueOptions())
+	var (
+		mu      sync.Mutex
+		results []error
+	)
+	done := func(err error) {
+		mu.Lock()
+		results = append(results, err)
+		mu.Unlock()
+	}
+	require.NoError(t, q.pushReport(1, tgbotapi.NewMessage(1, "first"), done))
+	require.NoError(t, q.pushReport(1, tgbotapi.NewMessage(1, "second"), done))
+	q.start()
+	q.close(50 * time.Millisecond)
+
+	// оба сообщения чата на паузе отменены, а не потеряны молча
+	assert.Equal(t, []error{errorApi.ErrQueueClosed, errorApi.ErrQueueClosed}, results)
+}
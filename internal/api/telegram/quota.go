@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/notice"
+)
+
+// команда /quota: заполненность Диска
+func (tg *TelegramApi) quota(chatID int64) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+		return
+	}
+	info, err := tg.yandexApi.DiskInfo()
+	if err != nil {
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespActionFailed)
+		return
+	}
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespQuota,
+		notice.HumanSize(info.UsedSpace),
+		notice.HumanSize(info.TotalSpace),
+		info.UsedPercent(),
+		notice.HumanSize(info.FreeSpace()),
+		notice.HumanSize(info.TrashSize),
+	))
+}
+
+// метод читает объем Диска, который периодически запрашивает API, и предупреждает админа
+func (tg *TelegramApi) listenQuota() {
+	for info := range tg.yandexApi.DiskInfoUpdated() {
+		tg.checkQuota(info)
+	}
+}
+
+// метод предупреждает админа, если заполненность Диска достигла нового порога
+// предупреждение о пороге повторяется, только если заполненность опускалась ниже него
+func (tg *TelegramApi) checkQuota(info *models.DiskInfo) {
+	level := quotaLevel(info.UsedPercent(), tg.quotaThresholds)
+	alerted := tg.quotaAlerted
+	if level != alerted {
+		tg.quotaAlerted = level
+		// порог сохраняется, чтобы после перезапуска не предупреждать о нем повторно
+		if err := tg.store.SaveQuotaAlerted(level); err != nil {
+			slog.With(slog.Any("error", err)).Error("save quota alert to storage failed")
+		}
+	}
+	if level <= alerted {
+		return
+	}
+	slog.With(
+		slog.Float64("used_percent", info.UsedPercent()),
+		slog.Int("threshold", level),
+	).Warn("disk quota threshold reached")
+	tg.notifyAdmins(fmt.Sprintf(config.RespQuotaAlert,
+		info.UsedPercent(),
+		level,
+		notice.HumanSize(info.UsedSpace),
+		notice.HumanSize(info.TotalSpace),
+		notice.HumanSize(info.TrashSize),
+	), nil)
+}
+
+// функция возвращает наибольший достигнутый порог, 0 - ни один порог не достигнут
+func quotaLevel(percent float64, thresholds []int) int {
+	level := 0
+	for _, threshold := range thresholds {
+		if percent >= float64(threshold) && threshold > level {
+			level = threshold
+		}
+	}
+	return level
+}
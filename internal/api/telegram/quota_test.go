@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaLevel(t *testing.T) {
+	thresholds := []int{90, 80, 95}
+	assert.Equal(t, 0, quotaLevel(79.9, thresholds))
+	assert.Equal(t, 80, quotaLevel(80, thresholds))
+	assert.Equal(t, 90, quotaLevel(94.5, thresholds))
+	assert.Equal(t, 95, quotaLevel(100, thresholds))
+	assert.Equal(t, 0, quotaLevel(100, nil))
+}
+
+func TestCheckQuotaRepeat(t *testing.T) {
+	tg := &TelegramApi{store: storage.NewMemoryStorage(), quotaThresholds: []int{80, 90}}
+	disk := func(used int64) *models.DiskInfo {
+		return &models.DiskInfo{TotalSpace: 100, UsedSpace: used}
+	}
+	tg.checkQuota(disk(85))
+	assert.Equal(t, 80, tg.quotaAlerted)
+	tg.checkQuota(disk(92))
+	assert.Equal(t, 90, tg.quotaAlerted)
+	// после очистки места порог снова сработает
+	tg.checkQuota(disk(50))
+	assert.Equal(t, 0, tg.quotaAlerted)
+
+	// порог переживает перезапуск
+	tg.checkQuota(disk(95))
+	restarted := &TelegramApi{store: tg.store}
+	require.NoError(t, restarted.loadState())
+	assert.Equal(t, 90, restarted.quotaAlerted)
+}
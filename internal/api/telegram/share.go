@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// функция приводит путь, введенный пользователем, к пути Яндекс Диска: "/папка/файл" -> "disk:/папка/файл"
+func diskPath(arg string) string {
+	return models.DiskPrefix + models.NormalizeFolder(arg)
+}
+
+// команда /share <путь>: публикует файл и отправляет публичную ссылку
+// публичная ссылка открывает файл всем, поэтому команда доступна только администраторам
+func (tg *TelegramApi) share(chatID int64, arg string) {
+	if !tg.checkShare(chatID, arg, config.ShareCmd) {
+		return
+	}
+	tg.publicLink(chatID, diskPath(arg))
+}
+
+// команда /unshare <путь>: закрывает доступ к файлу по публичной ссылке
+func (tg *TelegramApi) unshare(chatID int64, arg string) {
+	if !tg.checkShare(chatID, arg, config.UnshareCmd) {
+		return
+	}
+	tg.unpublish(chatID, diskPath(arg))
+}
+
+// метод проверяет, можно ли выполнить команду публикации, и отвечает пользователю, если нельзя
+func (tg *TelegramApi) checkShare(chatID int64, arg, cmd string) bool {
+	switch {
+	case tg.yandexApi == nil:
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+	case strings.TrimSpace(arg) == "":
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNeedPath, cmd))
+	default:
+		return true
+	}
+	return false
+}
+
+// метод публикует файл и отправляет в чат публичную ссылку с кнопкой закрытия доступа
+func (tg *TelegramApi) publicLink(chatID int64, filePath string) {
+	link, err := tg.yandexApi.Publish(filePath)
+	if err != nil {
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespActionFailed)
+		return
+	}
+	slog.Info(fmt.Sprintf("chat_id: %v; опубликован файл %s", chatID, filePath))
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(config.RespPublicLink, models.StripScheme(filePath), link))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnUnshare, tg.actionKeys.data(actionUnshare, filePath)),
+	))
+	tg.send(chatID, msg)
+}
+
+// метод закрывает доступ к файлу по публичной ссылке
+func (tg *TelegramApi) unpublish(chatID int64, filePath string) {
+	if err := tg.yandexApi.Unpublish(filePath); err != nil {
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespActionFailed)
+		return
+	}
+	slog.Info(fmt.Sprintf("chat_id: %v; закрыт доступ к файлу %s", chatID, filePath))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespUnpublished, models.StripScheme(filePath)))
+}
@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// команда /status: состояние уведомлений чата, опроса Диска и авторизации
+// команда не требует авторизации, чтобы пользователь мог узнать, что токен истек
+func (tg *TelegramApi) status(chatID int64) {
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespStatus,
+		tg.listenerStatus(chatID),
+		tg.chatFilters(chatID),
+		tg.pollStatus(),
+		tg.noticeStatus(chatID),
+		tg.tokenStatus(),
+	))
+}
+
+// метод возвращает состояние чтения уведомлений чатом
+func (tg *TelegramApi) listenerStatus(chatID int64) string {
+	state, err := tg.listenerState(chatID)
+	switch {
+	case errors.Is(err, errorApi.ErrNoListener):
+		return fmt.Sprintf(config.StatusNotListening, config.SendCmd)
+	case state:
+		return config.ListenerOn
+	default:
+		return config.ListenerOff
+	}
+}
+
+// метод возвращает время последнего успешного опроса Диска
+func (tg *TelegramApi) pollStatus() string {
+	if tg.yandexApi == nil {
+		return config.StatusNoDisk
+	}
+	last := tg.yandexApi.LastPoll()
+	if last.IsZero() {
+		return config.StatusNever
+	}
+	return last.Format(time.DateTime)
+}
+
+// метод возвращает последнее уведомление, доставленное в чат
+func (tg *TelegramApi) noticeStatus(chatID int64) string {
+	n, ok := tg.lastNotice(chatID)
+	if !ok {
+		return config.StatusNever
+	}
+	return fmt.Sprintf(config.StatusNotice, n.at.Format(time.DateTime), n.name)
+}
+
+// метод возвращает состояние OAuth токена
+func (tg *TelegramApi) tokenStatus() string {
+	if tg.yandexApi == nil {
+		return config.StatusNoDisk
+	}
+	switch tg.tokenState() {
+	case models.TokenValid:
+		return fmt.Sprintf(config.StatusTokenValid, tg.currentToken().ExpiresAt.Format(time.DateTime))
+	case models.TokenExpiringSoon:
+		return fmt.Sprintf(config.StatusTokenSoon, tg.currentToken().ExpiresAt.Format(time.DateTime))
+	default:
+		return config.StatusTokenExpired
+	}
+}
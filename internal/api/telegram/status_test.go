@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// заглушка Диска с временем последнего опроса
+type pollYandexApi struct {
+	yandexdisk.YandexDiskApi
+	last time.Time
+}
+
+func (s pollYandexApi) LastPoll() time.Time {
+	return s.last
+}
+
+func TestStatus(t *testing.T) {
+	polled := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	expires := time.Now().Add(48 * time.Hour)
+	tg := &TelegramApi{
+		yandexApi:     pollYandexApi{last: polled},
+		token:         &models.Token{Value: "token", ExpiresAt: expires},
+		expiresSoon:   time.Hour,
+		listeners:     map[int64]bool{1: true, 2: false},
+		subscriptions: map[int64][]string{1: {"/Общее"}},
+	}
+	tg.markNotice(1, "отчет.docx", nil)
+	// недоставленное уведомление не становится последним
+	tg.markNotice(2, "фото.jpg", assert.AnError)
+
+	n, ok := tg.lastNotice(1)
+	assert.True(t, ok)
+	assert.Equal(t, "читает", tg.listenerStatus(1))
+	assert.Equal(t, "/Общее", tg.chatFilters(1))
+	assert.Equal(t, "2024-06-01 12:00:00", tg.pollStatus())
+	assert.Equal(t, n.at.Format(time.DateTime)+", отчет.docx", tg.noticeStatus(1))
+	assert.Equal(t, "токен действует до "+expires.Format(time.DateTime), tg.tokenStatus())
+
+	assert.Equal(t, "на паузе", tg.listenerStatus(2))
+	assert.Equal(t, "еще не было", tg.noticeStatus(2))
+	assert.Equal(t, "не запущены, начать - /send", tg.listenerStatus(3))
+
+	// опросов еще не было, токен истек
+	tg.yandexApi = pollYandexApi{}
+	tg.token.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.Equal(t, "еще не было", tg.pollStatus())
+	assert.Equal(t, "токена нет или он истек, администратору нужно авторизоваться", tg.tokenStatus())
+}
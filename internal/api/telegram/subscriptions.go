@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// функция проверяет, лежит ли файл path в одной из папок folders
+// если подписок нет, то подходит любой файл
+func matchFolders(path string, folders []string) bool {
+	if len(folders) == 0 {
+		return true
+	}
+	for _, folder := range folders {
+		if models.InFolder(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// функция проверяет, лежит ли файл path в одной из скрытых папок mutes
+func mutedFolder(path string, mutes []string) bool {
+	for _, folder := range mutes {
+		if models.InFolder(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// метод возвращает обновления, которые подходят под подписки чата и не лежат в скрытых папках
+// метод вызывается под блокировкой tg.mu
+func (tg *TelegramApi) filterForChat(chatID int64, data *models.UpdateInfoSlice) models.UpdateInfoSlice {
+	folders := tg.subscriptions[chatID]
+	mutes := tg.mutes[chatID]
+	if len(folders) == 0 && len(mutes) == 0 {
+		return *data
+	}
+	var filtered models.UpdateInfoSlice
+	for _, elem := range *data {
+		if matchFolders(elem.Path, folders) && !mutedFolder(elem.Path, mutes) {
+			filtered = append(filtered, elem)
+		}
+	}
+	return filtered
+}
+
+// метод подписывает чат на папку, без аргумента выводит текущие подписки
+func (tg *TelegramApi) subscribe(chatID int64, arg string) {
+	if strings.TrimSpace(arg) == "" {
+		tg.listSubscriptions(chatID)
+		return
+	}
+	folder := models.NormalizeFolder(arg)
+	tg.mu.Lock()
+	if slices.Contains(tg.subscriptions[chatID], folder) {
+		tg.mu.Unlock()
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespSubscribedAlready, folder))
+		return
+	}
+	tg.subscriptions[chatID] = append(tg.subscriptions[chatID], folder)
+	folders := slices.Clone(tg.subscriptions[chatID])
+	tg.mu.Unlock()
+	tg.saveSubscriptions(chatID, folders)
+	slog.Info(fmt.Sprintf("chat_id: %v; подписка на папку %s", chatID, folder))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespSubscribed, folder))
+}
+
+// метод отписывает чат от папки
+func (tg *TelegramApi) unsubscribe(chatID int64, arg string) {
+	if strings.TrimSpace(arg) == "" {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNeedFolder, config.UnsubscribeCmd))
+		return
+	}
+	folder := models.NormalizeFolder(arg)
+	tg.mu.Lock()
+	index := slices.Index(tg.subscriptions[chatID], folder)
+	if index == -1 {
+		tg.mu.Unlock()
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespNotSubscribed, folder))
+		return
+	}
+	folders := slices.Delete(slices.Clone(tg.subscriptions[chatID]), index, index+1)
+	if len(folders) == 0 {
+		delete(tg.subscriptions, chatID)
+	} else {
+		tg.subscriptions[chatID] = slices.Clone(folders)
+	}
+	tg.mu.Unlock()
+	tg.saveSubscriptions(chatID, folders)
+	slog.Info(fmt.Sprintf("chat_id: %v; отписка от папки %s", chatID, folder))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespUnsubscribed, folder))
+}
+
+// метод выводит папки, на которые подписан чат
+func (tg *TelegramApi) listSubscriptions(chatID int64) {
+	tg.mu.RLock()
+	folders := slices.Clone(tg.subscriptions[chatID])
+	tg.mu.RUnlock()
+	if len(folders) == 0 {
+		tg.sendMsg(chatID, config.RespNoSubscriptions)
+		return
+	}
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespSubscriptions, strings.Join(folders, "\n")))
+}
+
+// метод сохраняет подписки чата в хранилище
+func (tg *TelegramApi) saveSubscriptions(chatID int64, folders []string) {
+	if err := tg.store.SaveSubscriptions(chatID, folders); err != nil {
+		slog.With(slog.Any("error", err)).Error("save subscriptions to storage failed")
+	}
+}
+
+// метод скрывает уведомления из папки для чата
+func (tg *TelegramApi) mute(chatID int64, folder string) {
+	folder = models.NormalizeFolder(folder)
+	tg.mu.Lock()
+	if slices.Contains(tg.mutes[chatID], folder) {
+		tg.mu.Unlock()
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespFolderMutedAlready, folder))
+		return
+	}
+	tg.mutes[chatID] = append(tg.mutes[chatID], folder)
+	folders := slices.Clone(tg.mutes[chatID])
+	tg.mu.Unlock()
+	tg.saveMutes(chatID, folders)
+	slog.Info(fmt.Sprintf("chat_id: %v; скрыта папка %s", chatID, folder))
+	// под ответом кнопка, чтобы сразу вернуть папку
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(config.RespFolderMuted, folder))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(config.BtnUnmute, tg.actionKeys.data(actionUnmute, folder)),
+	))
+	tg.send(chatID, msg)
+}
+
+// метод возвращает уведомления из скрытой папки, без аргумента выводит скрытые папки
+func (tg *TelegramApi) unmute(chatID int64, arg string) {
+	if strings.TrimSpace(arg) == "" {
+		tg.listMutes(chatID)
+		return
+	}
+	folder := models.NormalizeFolder(arg)
+	tg.mu.Lock()
+	index := slices.Index(tg.mutes[chatID], folder)
+	if index == -1 {
+		tg.mu.Unlock()
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespFolderNotMuted, folder))
+		return
+	}
+	folders := slices.Delete(slices.Clone(tg.mutes[chatID]), index, index+1)
+	if len(folders) == 0 {
+		delete(tg.mutes, chatID)
+	} else {
+		tg.mutes[chatID] = slices.Clone(folders)
+	}
+	tg.mu.Unlock()
+	tg.saveMutes(chatID, folders)
+	slog.Info(fmt.Sprintf("chat_id: %v; возвращена папка %s", chatID, folder))
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespFolderUnmuted, folder))
+}
+
+// метод выводит скрытые папки чата
+func (tg *TelegramApi) listMutes(chatID int64) {
+	tg.mu.RLock()
+	folders := slices.Clone(tg.mutes[chatID])
+	tg.mu.RUnlock()
+	if len(folders) == 0 {
+		tg.sendMsg(chatID, config.RespNoMutes)
+		return
+	}
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespMutes, strings.Join(folders, "\n")))
+}
+
+// метод сохраняет скрытые папки чата в хранилище
+func (tg *TelegramApi) saveMutes(chatID int64, folders []string) {
+	if err := tg.store.SaveMutes(chatID, folders); err != nil {
+		slog.With(slog.Any("error", err)).Error("save mutes to storage failed")
+	}
+}
@@ -1,47 +1,127 @@
 package telegram
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
 	"github.com/VoC925/tgBotNotice/internal/config"
 	"github.com/VoC925/tgBotNotice/internal/errorApi"
 	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/notice"
+	"github.com/VoC925/tgBotNotice/internal/server"
+	"github.com/VoC925/tgBotNotice/internal/source"
+	"github.com/VoC925/tgBotNotice/internal/storage"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const (
+	authCodeLifetime = 10 * time.Minute // время жизни кода подтверждения Яндекса
+)
+
 // структура API telegram бота
 type TelegramApi struct {
-	bot *tgbotapi.BotAPI // структура телеграмм бота
-
-	yandexApi yandexdisk.YandexDiskApi // интерфейс API Яндекс Диска
-
-	updateCh   tgbotapi.UpdatesChannel // канал чтения сообщений от пользователя самого бота
-	authCodeCh chan string             // канал для передачи кода авторизации
-
-	admin       string        // никнейм админа
-	isAuthState bool          // состояние авторизации одно (только админ): true - пользователю отправлена ссылка авторизации
+	bot          *tgbotapi.BotAPI // структура телеграмм бота
+	queue        *outbox          // очередь исходящих сообщений
+	drainTimeout time.Duration    // время на отправку оставшихся сообщений при остановке
+	renderer     *notice.Renderer // формирование текста уведомлений
+	textOnly     bool             // true - загруженные файлы не отправляются фото и документами
+	mediaMaxSize int64            // максимальный размер отправляемого файла
+	mediaBatch   int64            // сколько байт вложений скачивается за один опрос
+
+	uploadFolder  string // папка для загрузки файлов из чата по умолчанию
+	uploadMaxSize int64  // максимальный размер загружаемого из чата файла
+
+	quotaThresholds []int // пороги заполненности Диска в процентах
+	quotaAlerted    int   // последний порог, о котором предупрежден админ, меняется только в listenQuota и хранится в store
+
+	source    source.Source            // источник событий с файлами
+	yandexApi yandexdisk.YandexDiskApi // интерфейс API Яндекс Диска, nil - если источник не Яндекс Диск
+	store     storage.Storage          // хранилище слушателей и токена
+
+	updateCh    tgbotapi.UpdatesChannel // канал чтения сообщений от пользователя самого бота
+	webhook     *webhookServer          // сервер для приема апдейтов, nil - если используется long polling
+	authCodeCh  chan string             // канал для передачи кода авторизации из чата
+	authTokenCh chan *models.Token      // канал для передачи токена, полученного через OAuth колбэк
+	oauthServer *server.OAuthServer     // сервер для приема redirect_uri, nil - если отключен
+	stopCh      chan struct{}           // канал остановки фоновых задач бота
+
+	muAuth    sync.Mutex // мьютекс для текущей авторизации
+	authState string     // одноразовый nonce текущей авторизации, пустой - nonce уже использован или авторизация не идет
+	authChat  int64      // чат, в котором запущена авторизация, 0 - авторизация не идет
+	authUser  int64      // пользователь, запустивший авторизацию, только он может ввести код
+	waitCode  bool       // true - пользователю отправлена ссылка авторизации и ожидается код в чате
+
+	owner       atomic.Int64  // user ID владельца бота, 0 - владелец еще не назначен
+	admins      []int64       // user ID администраторов из конфига
+	muToken     sync.RWMutex  // мьютекс для токена, так как токен обновляется в фоне
 	token       *models.Token // access токен
-
-	mu        sync.RWMutex   // мьютекс для мапы isSending
-	listeners map[int64]bool // состояние чтения уведомлений для каждого отдельного чата: true - читает, false - не читает
+	expiresSoon time.Duration // за сколько до истечения токен считается скоро истекающим
+	isPolling   atomic.Bool   // true - опрос источника уже запущен
+	draftSeq    atomic.Uint64 // номер последнего черновика сообщения администратора
+
+	mu            sync.RWMutex       // мьютекс для мап listeners, subscriptions, mutes и uploadFolders
+	listeners     map[int64]bool     // состояние чтения уведомлений для каждого отдельного чата: true - читает, false - не читает
+	subscriptions map[int64][]string // папки, на которые подписан чат, пусто - уведомления обо всех файлах
+	mutes         map[int64][]string // папки, уведомления из которых чат скрыл
+	uploadFolders map[int64]string   // папки, выбранные чатами для загрузки файлов
+
+	muMembers   sync.RWMutex            // мьютекс для мап members, requests и claimSecret
+	claimSecret string                  // секрет для команды /claim, пустой - владелец уже назначен
+	members     map[int64]models.Member // пользователи с выданным доступом, ключ - user ID
+	requests    map[int64]accessRequest // запросы доступа, ожидающие решения, ключ - user ID
+
+	muChats       sync.Mutex                // мьютекс для мап chats, chatMisses, deliverySaved и notices
+	chats         map[int64]models.ChatInfo // названия, типы чатов и время последней доставки, для слушателей хранятся в store
+	chatMisses    map[int64]bool            // чаты, которые не удалось запросить у Telegram, повторно не запрашиваются
+	deliverySaved map[int64]time.Time       // время доставки, которое последним записано в store
+	notices       map[int64]deliveredNotice // последнее доставленное в чат уведомление с момента запуска
+
+	actionKeys *actionKeys // пути файлов и папок для кнопок под уведомлениями
+
+	muFind sync.Mutex             // мьютекс для кэша поиска
+	finds  map[findKey]findResult // результаты /find для листания страниц
 }
 
 // конструктор структуры TelegramApi
-func NewTelegramApi() (*TelegramApi, error) {
+// store - хранилище, из которого восстанавливаются слушатели и токен
+// src - источник событий с файлами, если это Яндекс Диск, то включается OAuth авторизация
+func NewTelegramApi(store storage.Storage, src source.Source) (*TelegramApi, error) {
 	// берем конфиг
 	cfg := config.ConfigInstance
 
 	tgApi := &TelegramApi{
-		listeners:   make(map[int64]bool),
-		isAuthState: false,
+		store:       store,
+		source:      src,
 		authCodeCh:  make(chan string),
+		authTokenCh: make(chan *models.Token),
 		token:       nil,
 		mu:          sync.RWMutex{},
+		actionKeys:  newActionKeys(actionKeysLimit),
+		requests:    make(map[int64]accessRequest),
+		chatMisses:  make(map[int64]bool),
+		stopCh:      make(chan struct{}),
+		notices:     make(map[int64]deliveredNotice),
+	}
+
+	// формат уведомлений
+	renderer, err := notice.NewRenderer(cfg.Notice.ParseMode, cfg.Notice.Template)
+	if err != nil {
+		return nil, err
+	}
+	tgApi.renderer = renderer
+
+	// восстановление состояния из хранилища
+	if err := tgApi.loadState(); err != nil {
+		return nil, err
 	}
 
 	bot, err := tgbotapi.NewBotAPIWithClient(
@@ -57,31 +137,168 @@ func NewTelegramApi() (*TelegramApi, error) {
 	}
 	tgApi.bot = bot
 
+	// очередь исходящих сообщений
+	tgApi.queue = newOutbox(tgApi.deliver, tgApi.deadLetter, queueOptions{
+		size:         cfg.Queue.Size,
+		globalRate:   cfg.Queue.GlobalRate,
+		chatInterval: cfg.Queue.ChatInterval,
+		maxAttempts:  cfg.Queue.MaxAttempts,
+		backoffBase:  cfg.Queue.BackoffBase,
+		backoffMax:   cfg.Queue.BackoffMax,
+	})
+	tgApi.drainTimeout = cfg.Queue.DrainTimeout
+	tgApi.textOnly = cfg.Media.TextOnly
+	tgApi.mediaMaxSize = cfg.Media.MaxSize
+	tgApi.mediaBatch = cfg.Media.BatchMaxSize
+	tgApi.uploadFolder = models.NormalizeFolder(cfg.Disk.UploadFolder)
+	tgApi.uploadMaxSize = cfg.Disk.UploadMaxSize
+	tgApi.quotaThresholds = cfg.Quota.Thresholds
+
 	// API для яндекс диска
-	tgApi.yandexApi = yandexdisk.NewYandexDiskAPI()
+	if yandexApi, ok := src.(yandexdisk.YandexDiskApi); ok {
+		tgApi.yandexApi = yandexApi
+	}
+
+	// сервер для OAuth колбэка
+	if cfg.Server.Enabled && tgApi.yandexApi != nil {
+		tgApi.oauthServer = server.NewOAuthServer(
+			cfg.Server.Host,
+			cfg.Server.Port,
+			cfg.Server.CallbackPath,
+			tgApi.yandexApi,
+			tgApi,
+		)
+	}
 
 	// уровень debug
 	tgApi.bot.Debug = cfg.Telegram.IsDebug
 
 	// настройка updates
-	u := tgbotapi.NewUpdate(cfg.Telegram.Offset)
-	u.Timeout = cfg.Telegram.TimeoutUpdate
-	tgApi.updateCh = tgApi.bot.GetUpdatesChan(u)
+	if cfg.Webhook.Enabled {
+		// апдейты приходят на собственный HTTP(S) сервер
+		updateCh, err := tgApi.startWebhook(cfg)
+		if err != nil {
+			return nil, err
+		}
+		tgApi.updateCh = updateCh
+	} else {
+		// если ранее бот работал через webhook, то long polling не заработает, пока webhook не удален
+		if err := tgApi.deleteWebhook(); err != nil {
+			return nil, err
+		}
+		u := tgbotapi.NewUpdate(cfg.Telegram.Offset)
+		u.Timeout = cfg.Telegram.TimeoutUpdate
+		tgApi.updateCh = tgApi.bot.GetUpdatesChan(u)
+	}
 
-	// установка админа
-	tgApi.admin = cfg.Telegram.Admin
+	// владелец из конфига важнее назначенного командой /claim
+	tgApi.admins = cfg.Telegram.Admins
+	if cfg.Telegram.OwnerID != 0 {
+		tgApi.owner.Store(cfg.Telegram.OwnerID)
+	}
+	if tgApi.owner.Load() == 0 {
+		if err := tgApi.newClaimSecret(); err != nil {
+			return nil, err
+		}
+	}
+	tgApi.expiresSoon = cfg.Telegram.TimeRefreshToken
 
 	slog.With(
 		slog.String("bot_account", tgApi.bot.Self.UserName),
 		slog.String("timeout_client", cfg.Api.Timeout.String()),
 		slog.Int("timeout_update", cfg.Telegram.TimeoutUpdate),
+		slog.Int("listeners", len(tgApi.listeners)),
 	).Info("bot created")
 	return tgApi, nil
 }
 
+// метод загружает слушателей и токен из хранилища
+func (tg *TelegramApi) loadState() error {
+	listeners, err := tg.store.Listeners()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.listeners = listeners
+
+	subscriptions, err := tg.store.Subscriptions()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.subscriptions = subscriptions
+
+	mutes, err := tg.store.Mutes()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.mutes = mutes
+
+	uploadFolders, err := tg.store.UploadFolders()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.uploadFolders = uploadFolders
+
+	members, err := tg.store.Members()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.members = members
+
+	owner, err := tg.store.Owner()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.owner.Store(owner)
+
+	chats, err := tg.store.Chats()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.chats = chats
+	tg.deliverySaved = make(map[int64]time.Time, len(chats))
+	for chatID, info := range chats {
+		tg.deliverySaved[chatID] = info.Delivered
+	}
+
+	quotaAlerted, err := tg.store.QuotaAlerted()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	tg.quotaAlerted = quotaAlerted
+
+	t, err := tg.store.Token()
+	switch {
+	case errors.Is(err, errorApi.ErrTokenNotExist):
+		// админ еще не авторизовался
+	case err != nil:
+		return fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	default:
+		tg.token = t
+		slog.Info("access токен восстановлен из хранилища")
+	}
+	return nil
+}
+
 // метод запуска телеграм бота
 func (tg *TelegramApi) Start() {
 	slog.Info("bot working started succesfully")
+	// отправка сообщений из очереди
+	tg.queue.start()
+	// время доставки записывается в хранилище в фоне, а не при каждой отправке
+	go tg.persistDeliveries(tg.stopCh)
+	// если токен был восстановлен из хранилища, то сразу запускаем опрос API
+	tg.refreshExpiredToken()
+	if tg.isAuthorized() {
+		tg.startPolling()
+	}
+	// сервер для приема кода авторизации
+	if tg.oauthServer != nil {
+		go func() {
+			if err := tg.oauthServer.Start(); err != nil {
+				slog.With(slog.Any("error", err)).Error("oauth callback server failed")
+			}
+		}()
+	}
 	// метод отправляющий
 	tg.listenUpdates()
 }
@@ -90,12 +307,29 @@ func (tg *TelegramApi) Start() {
 func (tg *TelegramApi) listenUpdates() {
 	// чтение из канала updates
 	for update := range tg.updateEventCh() {
-		if update.Message != nil { // If we got a message
+		// нажата кнопка под уведомлением
+		if update.CallbackQuery != nil {
+			go tg.handleCallback(update.CallbackQuery)
+			continue
+		}
+		// бота заблокировали или удалили из чата
+		if update.MyChatMember != nil {
+			tg.handleMyChatMember(update.MyChatMember)
+			continue
+		}
+		// группа преобразована в супергруппу
+		if update.Message != nil && update.Message.MigrateToChatID != 0 {
+			tg.migrateListener(update.Message.Chat.ID, update.Message.MigrateToChatID)
+			continue
+		}
+		if update.Message != nil && update.Message.From != nil { // If we got a message
 			slog.Info(fmt.Sprintf("chat_id: %v; user: %s; msg receieved: %s",
 				update.Message.Chat.ID,
 				update.Message.From.UserName,
 				update.Message.Text,
 			))
+			// название чата нужно для списка слушателей
+			tg.rememberChat(update.Message.Chat)
 			// логика обработки сообщения в горутине, метод обрабатывается в горутине
 			// так как чтение из канала уведомлений является блокирующей операцией, то
 			// необходимо продолжать читать сообщения полльзователя
@@ -118,116 +352,187 @@ func (tg *TelegramApi) updateEventCh() tgbotapi.UpdatesChannel {
 
 // метод для обработки сообщений
 func (tg *TelegramApi) handleMsg(msg *tgbotapi.Message) error {
-	// контекст для запроса к API
 	chatID := msg.Chat.ID
-	from := msg.From.UserName
+	role := tg.roleOf(msg.From)
 	// пришла команда
 	if msg.IsCommand() {
 		// обнуление состояния авторизации, если ранее админ запустил процесс авторизации
 		// и вместо того, чтобы ввести код авторизации ввел новую команду
-		// команда только для админа
-		if tg.isAuthState && tg.isAdmin(from) {
-			tg.isAuthState = false
+		tg.takeAuthCode(chatID, msg.From)
+		// проверка прав по таблице команд
+		perm, ok := commandPermissions[msg.Command()]
+		if !ok {
+			// случай, если пользователь отправил не известную команду
+			tg.sendMsg(chatID, config.RespUnknownCmd)
+			return nil
+		}
+		if role < perm.role {
+			tg.denyAccess(chatID, role, perm.role)
+			return nil
+		}
+		// команды требующие авторизации на Диске
+		if perm.auth && !tg.isAuthorized() {
+			tg.sendMsg(chatID, config.RespNeedAuth)
+			return nil
 		}
-		// команды не требующие авторизации
 		switch msg.Command() {
 		case config.AuthCmd:
-			// команда только для админа
-			tg.auth(chatID, from)
-			return nil
+			tg.auth(chatID, msg.From.ID)
 		case config.InfoCmd:
 			tg.info(chatID)
-			return nil
 		case config.SpecialCmd:
 			tg.specialFeature(chatID)
-			return nil
+		case config.RequestCmd:
+			// запрос доступа к боту
+			tg.requestAccess(chatID, msg.From)
+		case config.ClaimCmd:
+			// назначение владельца по секрету из журнала
+			tg.claim(msg)
 		case config.DeleteListeners:
-			tg.deleteAllListeners(chatID, from)
-			return nil
-		default:
-			// tg.sendMsg(chatID, config.RespUnknownCmd)
-			// return nil
-		}
-		// команды требующие авторизации пользователя
-		if tg.isAuthorized() {
-			switch msg.Command() {
-			case config.SendCmd:
-				// старт чтения уведомлений
-				tg.startSendNotice(chatID)
-				return nil
-			case config.StopCmd:
-				// остановка чтения уведомления
-				tg.stopSendNotice(chatID)
-				return nil
-			default:
-				// случай, если пользователь отправил не известную команду
-				tg.sendMsg(chatID, config.RespUnknownCmd)
-				return nil
-			}
-		} else {
-			tg.sendMsg(chatID, config.RespNeedAuth)
-			return nil
+			tg.deleteAllListeners(chatID)
+		case config.MembersCmd:
+			// пользователи с доступом
+			tg.listMembers(chatID)
+		case config.RoleCmd:
+			// изменить роль пользователя
+			tg.setRole(chatID, msg.CommandArguments())
+		case config.BroadcastCmd:
+			// сообщение всем слушателям
+			tg.broadcast(chatID, msg.CommandArguments())
+		case config.NotifyCmd:
+			// сообщение в один чат
+			tg.notify(chatID, msg.CommandArguments())
+		case config.ListenersCmd:
+			// список слушателей
+			tg.listListeners(chatID)
+		case config.KickCmd:
+			// удалить слушателя
+			tg.kick(chatID, msg.CommandArguments())
+		case config.PauseCmd:
+			// приостановить уведомления в чат
+			tg.pause(chatID, msg.CommandArguments())
+		case config.ResumeCmd:
+			// возобновить уведомления в чат
+			tg.resume(chatID, msg.CommandArguments())
+		case config.SendCmd:
+			// старт чтения уведомлений
+			tg.startSendNotice(chatID)
+		case config.StopCmd:
+			// остановка чтения уведомления
+			tg.stopSendNotice(chatID)
+		case config.SubscribeCmd:
+			// подписка на папку
+			tg.subscribe(chatID, msg.CommandArguments())
+		case config.UnsubscribeCmd:
+			// отписка от папки
+			tg.unsubscribe(chatID, msg.CommandArguments())
+		case config.UnmuteCmd:
+			// вернуть уведомления из скрытой папки
+			tg.unmute(chatID, msg.CommandArguments())
+		case config.ShareCmd:
+			// публичная ссылка на файл
+			tg.share(chatID, msg.CommandArguments())
+		case config.UnshareCmd:
+			// закрыть доступ по публичной ссылке
+			tg.unshare(chatID, msg.CommandArguments())
+		case config.FolderCmd:
+			// папка для загрузки файлов из чата
+			tg.folder(chatID, msg.CommandArguments())
+		case config.LsCmd:
+			// содержимое папки Диска
+			tg.list(chatID, msg.CommandArguments())
+		case config.FindCmd:
+			// поиск файлов по имени
+			tg.find(chatID, msg.CommandArguments())
+		case config.RecentCmd:
+			// последние загруженные файлы
+			tg.recent(chatID, msg.CommandArguments())
+		case config.QuotaCmd:
+			// заполненность Диска
+			tg.quota(chatID)
+		case config.StatusCmd:
+			// состояние уведомлений чата
+			tg.status(chatID)
 		}
+		return nil
 	}
 	// ответ, если пришла не команда, а просто сообщение
-	if tg.isAuthState && tg.isAdmin(from) { // если ожидается код авторизации от админа
-		// отправляем код в канал
-		tg.authCodeCh <- msg.Text
-		tg.isAuthState = false
+	if tg.takeAuthCode(chatID, msg.From) { // если ожидается код авторизации от этого админа в этом чате
+		// отправляем код в канал, если авторизация уже завершилась, то код никто не ждет
+		select {
+		case tg.authCodeCh <- msg.Text:
+		default:
+			tg.sendMsg(chatID, config.RespAuthTimeout)
+		}
+		return nil
+	}
+	// документ или фото загружаются на Диск
+	if msg.Document != nil || len(msg.Photo) > 0 {
+		tg.uploadFromChat(msg)
 		return nil
 	}
 	tg.sendMsg(msg.Chat.ID, config.RespOnlyCmd)
 	return nil
 }
 
-func (tg *TelegramApi) auth(chatID int64, from string) {
-	if tg.token != nil {
-		tg.sendMsg(chatID, config.RespAuthorizedAlready)
+// команда только для администраторов
+// userID - администратор, запустивший авторизацию
+func (tg *TelegramApi) auth(chatID, userID int64) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespAuthNotRequired)
 		return
 	}
-	if tg.isAdmin(from) {
-		tg.authorize(chatID)
-		return
-	}
-	tg.sendMsg(chatID, config.RespOnlyAdmin)
+	tg.authorize(chatID, userID)
 }
 
 // метод отправляет всем слушателям из мапы listener данные
 func (tg *TelegramApi) sendToListeners(data *models.UpdateInfoSlice) {
+	// обновления для чатов собираются под блокировкой, а в очередь ставятся после нее,
+	// так как при заполненной очереди постановка ждет
+	updates := make(map[int64]models.UpdateInfoSlice)
 	tg.mu.RLock()
-	if len(tg.listeners) == 0 {
-		// если пока нет слушателей, то выходим
-		tg.mu.RUnlock()
-		return
-	}
 	for chatID, value := range tg.listeners {
-		if value {
-			// если chat_id имеет состояние true на чтении
-			tg.sendMsg(chatID, data.String())
+		if !value {
+			continue
+		}
+		// если chat_id имеет состояние true на чтении, отправляем файлы из папок, на которые он подписан
+		filtered := tg.filterForChat(chatID, data)
+		if len(filtered) == 0 {
+			continue
 		}
+		updates[chatID] = filtered
 	}
 	tg.mu.RUnlock()
+	if len(updates) == 0 {
+		return
+	}
+	// файлы скачиваются один раз для всех чатов и только те, что получит хотя бы один чат
+	attachments := tg.loadAttachments(receivedUpdates(*data, updates))
+	for chatID, filtered := range updates {
+		tg.sendUpdates(chatID, filtered, attachments)
+	}
 }
 
 // метод удаляющий всех слушателей, кроме самого админа
-// команда только для админа
-func (tg *TelegramApi) deleteAllListeners(chatID int64, from string) {
-	// проверка, админ ли отправил команду
-	if tg.isAdmin(from) {
-		tg.mu.Lock()
-		for key := range tg.listeners {
-			if key == chatID {
-				// chat_id принадлежит админу
-				continue
-			}
-			// удаляем пару ключ-значение
-			delete(tg.listeners, key)
+// команда только для администраторов
+func (tg *TelegramApi) deleteAllListeners(chatID int64) {
+	// слушатели собираются под блокировкой, а удаляются после нее,
+	// так как удаление из хранилища записывает файл
+	tg.mu.RLock()
+	chats := make([]int64, 0, len(tg.listeners))
+	for key := range tg.listeners {
+		if key == chatID {
+			// chat_id принадлежит админу
+			continue
 		}
-		tg.mu.Unlock()
-		slog.Info("Все слушатели удалены из мапы listeners")
-		return
+		chats = append(chats, key)
+	}
+	tg.mu.RUnlock()
+	for _, key := range chats {
+		tg.removeListener(key)
 	}
-	tg.sendMsg(chatID, config.RespOnlyAdmin)
+	slog.Info("Все слушатели удалены из мапы listeners")
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespListenersDeleted, len(chats)))
 }
 
 // метод возвращает какое состояние чтения у заданного chatID
@@ -249,6 +554,16 @@ func (tg *TelegramApi) addListener(chatID int64) {
 	tg.mu.Lock()
 	tg.listeners[chatID] = false
 	tg.mu.Unlock()
+	if err := tg.store.SaveListener(chatID, false); err != nil {
+		slog.With(slog.Any("error", err)).Error("save listener to storage failed")
+	}
+	// название чата уже известно из его сообщения, но до этого чат не был слушателем
+	tg.muChats.Lock()
+	info, ok := tg.chats[chatID]
+	tg.muChats.Unlock()
+	if ok {
+		tg.saveChat(chatID, info)
+	}
 	slog.Info(fmt.Sprintf("chat_id: %v; добавлен в мапу listeners", chatID))
 }
 
@@ -257,14 +572,12 @@ func (tg *TelegramApi) changeStateListener(chatID int64, state bool) {
 	tg.mu.Lock()
 	tg.listeners[chatID] = state
 	tg.mu.Unlock()
+	if err := tg.store.SaveListener(chatID, state); err != nil {
+		slog.With(slog.Any("error", err)).Error("save listener to storage failed")
+	}
 	slog.Info(fmt.Sprintf("chat_id: %v; изменено состояние на %t", chatID, state))
 }
 
-// метод проверяет является ли пользователь админом
-func (tg *TelegramApi) isAdmin(from string) bool {
-	return from == tg.admin
-}
-
 func (tg *TelegramApi) info(chatID int64) {
 	tg.sendMsg(chatID,
 		fmt.Sprintf(config.RespInfo,
@@ -272,6 +585,26 @@ func (tg *TelegramApi) info(chatID int64) {
 			config.SendCmd,
 			config.StopCmd,
 			config.SendCmd,
+			config.SubscribeCmd,
+			config.UnsubscribeCmd,
+			config.UnmuteCmd,
+			config.ShareCmd,
+			config.UnshareCmd,
+			config.FolderCmd,
+			config.LsCmd,
+			config.FindCmd,
+			config.RecentCmd,
+			config.QuotaCmd,
+			config.StatusCmd,
+			config.RequestCmd,
+			config.MembersCmd,
+			config.RoleCmd,
+			config.BroadcastCmd,
+			config.NotifyCmd,
+			config.ListenersCmd,
+			config.KickCmd,
+			config.PauseCmd,
+			config.ResumeCmd,
 		),
 	)
 }
@@ -313,79 +646,292 @@ func (tg *TelegramApi) stopSendNotice(chatID int64) {
 	tg.sendMsg(chatID, config.RespStop)
 }
 
+// метод запускает опрос источника
+// для Яндекс Диска в API устанавливается текущий токен, если опрос уже идет - токен просто подменяется
+func (tg *TelegramApi) startPolling() {
+	if tg.yandexApi != nil {
+		tg.yandexApi.SetToken(tg.currentToken())
+	}
+	if !tg.isPolling.CompareAndSwap(false, true) {
+		return
+	}
+	go tg.sendingLoop()
+}
+
 // метод для отправки уведомлений всем слушателям из мапы listeners
 func (tg *TelegramApi) sendingLoop() {
-	// метод отправляющий уведомления в канал путем зпросов к API
-	go tg.yandexApi.UpdateDiskData(tg.token.Value)
+	// метод отправляющий уведомления в канал путем опроса источника
+	go tg.source.Watch()
+	// сохраняем токены, обновленные в фоне
+	if tg.yandexApi != nil {
+		go tg.listenTokenRefresh()
+		go tg.listenQuota()
+	}
 	// слушаем канал уведомлений
-	for data := range tg.yandexApi.Update() {
+	for data := range tg.source.Events() {
 		tg.sendToListeners(data)
 	}
-	slog.Info("выход из метода startSendNotice()")
+	slog.Info("выход из метода sendingLoop()")
 }
 
 // метод для отправки сообщений об ошибке
 // chatID - ID чата, куда отправить
 // msg - сообщение
 func (tg *TelegramApi) sendMsg(chatID int64, msg string) {
-	response := tgbotapi.NewMessage(chatID, msg)
-	tg.bot.Send(response)
+	tg.send(chatID, tgbotapi.NewMessage(chatID, msg))
+}
+
+// метод для отправки уведомления о файлах с разметкой и кнопками
+// name - файл, о котором уведомление, keyboard - кнопки под сообщением, nil - без кнопок
+func (tg *TelegramApi) sendNotice(chatID int64, name, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tg.renderer.ParseMode()
+	msg.DisableWebPagePreview = true
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	tg.sendFileNotice(chatID, name, msg)
+}
+
+// метод ставит уведомление о файле в очередь, после доставки оно становится последним уведомлением чата
+func (tg *TelegramApi) sendFileNotice(chatID int64, name string, c tgbotapi.Chattable) {
+	tg.sendReport(chatID, c, func(err error) {
+		tg.markNotice(chatID, name, err)
+	})
+}
+
+// метод ставит сообщение в очередь отправки
+func (tg *TelegramApi) send(chatID int64, c tgbotapi.Chattable) {
+	done := func(err error) { tg.markDelivered(chatID, err) }
+	if err := tg.queue.pushReport(chatID, c, done); err != nil {
+		slog.With(slog.Int64("chat_id", chatID)).Error(err.Error())
+	}
 }
 
-// авторизация доступна только один раз
-func (tg *TelegramApi) authorize(chatID int64) {
-	// если токена нет значит админ не авторизовался
-	if tg.token == nil {
-		tg.handleAuth(chatID)
+// метод ставит сообщение в очередь отправки, done вызывается, когда отправка завершена
+func (tg *TelegramApi) sendReport(chatID int64, c tgbotapi.Chattable, done func(err error)) {
+	report := func(err error) {
+		tg.markDelivered(chatID, err)
+		done(err)
+	}
+	if err := tg.queue.pushReport(chatID, c, report); err != nil {
+		slog.With(slog.Int64("chat_id", chatID)).Error(err.Error())
+		done(err)
+	}
+}
+
+// метод записывает сообщение, которое не удалось доставить, в хранилище
+// если чат недоступен, то слушатель удаляется, если чат перенесен - сообщение переотправляется и возвращается true
+// true также возвращается для правки, которая не меняет сообщение
+func (tg *TelegramApi) deadLetter(m outMsg, err error, attempts int) bool {
+	if tg.handleSendFailure(m, err) {
+		return true
+	}
+	slog.With(
+		slog.Int64("chat_id", m.chatID),
+		slog.Int("attempts", attempts),
+		slog.Any("error", err),
+	).Error(errorApi.ErrSendMessage.Error())
+	d := models.DeadLetter{
+		ChatID:   m.chatID,
+		Text:     m.text(),
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+	if err := tg.store.SaveDeadLetter(d); err != nil {
+		slog.With(slog.Any("error", err)).Error("save dead letter to storage failed")
+	}
+	return false
+}
+
+// метод сохраняет токены, которые API Яндекс Диска обновил по refresh токену
+func (tg *TelegramApi) listenTokenRefresh() {
+	for t := range tg.yandexApi.TokenRefreshed() {
+		tg.setToken(t)
+	}
+}
+
+// метод один раз обновляет по refresh токену токен, который истек, пока сервис не работал
+// если обновить не удалось, то администратору нужно авторизоваться заново
+func (tg *TelegramApi) refreshExpiredToken() {
+	token := tg.currentToken()
+	if tg.yandexApi == nil || token == nil || token.RefreshToken == "" || tg.tokenState() != models.TokenExpired {
 		return
 	}
-	// если токен протух
-	if !tg.token.IsValid() {
-		slog.Info("токен протух")
-		tg.handleAuth(chatID)
+	t, err := tg.yandexApi.RefreshToken(token.RefreshToken)
+	if err != nil {
+		slog.With(slog.Any("error", err)).Warn("refresh expired token failed")
 		return
 	}
-	slog.Info(fmt.Sprintf("chat_id: %v; токен есть и он валиден", chatID))
-	// если токен есть и он валиден
-	tg.sendMsg(chatID, config.RespAuthorizedAlready)
+	tg.setToken(t)
+	slog.With(slog.Time("expires_at", t.ExpiresAt)).Info("истекший access токен обновлен")
+}
+
+// метод возвращает текущий access токен
+func (tg *TelegramApi) currentToken() *models.Token {
+	tg.muToken.RLock()
+	defer tg.muToken.RUnlock()
+	return tg.token
+}
+
+// метод устанавливает access токен и сохраняет его в хранилище
+func (tg *TelegramApi) setToken(t *models.Token) {
+	tg.muToken.Lock()
+	tg.token = t
+	tg.muToken.Unlock()
+	if err := tg.store.SaveToken(t); err != nil {
+		slog.With(slog.Any("error", err)).Error("save token to storage failed")
+	}
+}
+
+// метод возвращает состояние авторизации:
+// TokenExpired - токена нет или он истек, TokenExpiringSoon - токен скоро истечет, TokenValid - токен валиден
+func (tg *TelegramApi) tokenState() models.TokenState {
+	token := tg.currentToken()
+	if token == nil {
+		return models.TokenExpired
+	}
+	return token.State(time.Now(), tg.expiresSoon)
 }
 
-func (tg *TelegramApi) handleAuth(chatID int64) {
-	// состояние авторизации у админа
-	tg.isAuthState = true
-	// если токена нет, значит отправляем пользователю ссылку авторизации
-	tg.sendMsg(chatID, fmt.Sprintf("%s:\n%s", config.RespLetsAuth, tg.yandexApi.AuthorizeURL()))
-	tg.sendMsg(chatID, config.RespSendCode)
-	// слушаем канал и ожидаем код подтверждения
-	for code := range tg.authCodeCh {
-		t, err := tg.yandexApi.RequestToken(code)
+// авторизация админа: ссылка отправляется, если токена нет, он истек или скоро истечет
+func (tg *TelegramApi) authorize(chatID, userID int64) {
+	switch tg.tokenState() {
+	case models.TokenValid:
+		slog.Info(fmt.Sprintf("chat_id: %v; токен есть и он валиден", chatID))
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespAuthorizedAlready, tg.currentToken().ExpiresAt.Format(time.DateTime)))
+	case models.TokenExpiringSoon:
+		slog.Info("токен скоро истечет")
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespTokenExpiringSoon, tg.currentToken().ExpiresAt.Format(time.DateTime)))
+		tg.handleAuth(chatID, userID)
+	default:
+		slog.Info("токена нет или он истек")
+		tg.handleAuth(chatID, userID)
+	}
+}
+
+func (tg *TelegramApi) handleAuth(chatID, userID int64) {
+	state, err := tg.beginAuth(chatID, userID)
+	if errors.Is(err, errorApi.ErrAuthInProgress) {
+		tg.sendMsg(chatID, config.RespAuthInProgress)
+		return
+	}
+	if err != nil {
+		slog.Error(err.Error())
+		tg.sendMsg(chatID, config.RespTokenFail)
+		return
+	}
+	defer tg.endAuth()
+	// отправляем пользователю ссылку авторизации
+	tg.sendMsg(chatID, fmt.Sprintf("%s:\n%s", config.RespLetsAuth, tg.yandexApi.AuthorizeURL(state)))
+	if tg.oauthServer != nil {
+		// код придет на сервер автоматически, ввод кода в чат остается запасным вариантом
+		tg.sendMsg(chatID, config.RespWaitCallback)
+	} else {
+		tg.sendMsg(chatID, config.RespSendCode)
+	}
+	// ожидаем код подтверждения из чата или токен из колбэка, пока код действителен
+	var t *models.Token
+	select {
+	case code := <-tg.authCodeCh:
+		t, err = tg.yandexApi.RequestToken(code)
 		if err != nil {
 			slog.Error(err.Error())
 			tg.sendMsg(chatID, config.RespAuthFail)
 			return
 		}
-		// сохраняем токен
-		tg.token = t
-		slog.Info("Добавлен новый access токен")
-		tg.sendMsg(chatID, config.RespAuthSuccess)
+	case t = <-tg.authTokenCh:
+	case <-time.After(authCodeLifetime):
+		slog.Info("время ожидания кода авторизации истекло")
+		tg.sendMsg(chatID, config.RespAuthTimeout)
+		return
+	}
+	// сохраняем токен
+	tg.setToken(t)
+	slog.With(slog.Time("expires_at", t.ExpiresAt)).Info("Добавлен новый access токен")
+	tg.sendMsg(chatID, config.RespAuthSuccess)
+
+	// запуск чтения из Api
+	tg.startPolling()
+}
 
-		// запуск чтения из Api
-		tg.sendingLoop()
+// метод начинает авторизацию в чате chatID и создает одноразовый nonce для параметра state ссылки авторизации
+// одновременно идет только одна авторизация, повторный запуск возвращает ErrAuthInProgress
+func (tg *TelegramApi) beginAuth(chatID, userID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %w", errorApi.ErrDoTokenRequest, err)
 	}
+	state := hex.EncodeToString(b)
+	tg.muAuth.Lock()
+	defer tg.muAuth.Unlock()
+	if tg.authChat != 0 {
+		return "", errorApi.ErrAuthInProgress
+	}
+	tg.authState = state
+	tg.authChat = chatID
+	tg.authUser = userID
+	tg.waitCode = true
+	return state, nil
 }
 
-func (tg *TelegramApi) isAuthorized() bool {
-	// если токена нет
-	if tg.token == nil {
+// метод сбрасывает состояние после завершения авторизации
+func (tg *TelegramApi) endAuth() {
+	tg.muAuth.Lock()
+	tg.authState = ""
+	tg.authChat = 0
+	tg.authUser = 0
+	tg.waitCode = false
+	tg.muAuth.Unlock()
+}
+
+// метод перестает ждать код, если его ждали от пользователя user в чате chatID
+// true - код ждали, сообщение пользователя нужно передать авторизации
+func (tg *TelegramApi) takeAuthCode(chatID int64, user *tgbotapi.User) bool {
+	if user == nil {
 		return false
 	}
-	// если токен протух
-	if tg.token.IsValid() {
+	tg.muAuth.Lock()
+	defer tg.muAuth.Unlock()
+	if !tg.waitCode || tg.authChat != chatID || tg.authUser != user.ID {
 		return false
 	}
+	tg.waitCode = false
 	return true
 }
 
+// метод проверяет state из OAuth колбэка, при совпадении nonce больше не принимается
+func (tg *TelegramApi) ConsumeState(state string) bool {
+	tg.muAuth.Lock()
+	defer tg.muAuth.Unlock()
+	if tg.authState == "" || subtle.ConstantTimeCompare([]byte(tg.authState), []byte(state)) != 1 {
+		return false
+	}
+	tg.authState = ""
+	return true
+}
+
+// метод передает токен из OAuth колбэка ожидающей авторизации
+func (tg *TelegramApi) ReceiveToken(t *models.Token) error {
+	select {
+	case tg.authTokenCh <- t:
+		return nil
+	case <-time.After(time.Second):
+		// авторизация уже завершилась по таймауту
+		return errorApi.ErrNoPendingAuth
+	}
+}
+
+// пользователи могут работать с сервисом, пока токен не истек
+// источникам кроме Яндекс Диска авторизация не нужна
+func (tg *TelegramApi) isAuthorized() bool {
+	if tg.yandexApi == nil {
+		return true
+	}
+	return tg.tokenState() != models.TokenExpired
+}
+
 func (tg *TelegramApi) specialFeature(chatID int64) {
 	msg := fmt.Sprintf("Для получения подробной информации перейдите по ссылке ниже:\n%s", config.FeatureURL)
 	tg.sendMsg(chatID, msg)
@@ -394,6 +940,23 @@ func (tg *TelegramApi) specialFeature(chatID int64) {
 // метод закрывающий канал update
 func (tg *TelegramApi) Close() error {
 	slog.Info("stop listening update chanel")
-	tg.bot.StopReceivingUpdates()
+	if tg.webhook != nil {
+		// удаляем webhook, чтобы при следующем запуске можно было вернуться к long polling
+		if err := tg.deleteWebhook(); err != nil {
+			slog.Error(err.Error())
+		}
+		if err := tg.webhook.close(); err != nil {
+			slog.With(slog.Any("error", err)).Error("webhook server shutdown failed")
+		}
+	} else {
+		tg.bot.StopReceivingUpdates()
+	}
+	// отправляем то, что осталось в очереди
+	tg.queue.close(tg.drainTimeout)
+	close(tg.stopCh)
+	tg.saveDeliveries()
+	if tg.oauthServer != nil {
+		return tg.oauthServer.Close()
+	}
 	return nil
 }
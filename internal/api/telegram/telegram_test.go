@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/api/yandexdisk"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// заглушка API Яндекс Диска, методы не вызываются
+type stubYandexApi struct {
+	yandexdisk.YandexDiskApi
+}
+
+func TestIsAuthorized(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name  string
+		token *models.Token
+		state models.TokenState
+		want  bool
+	}{
+		{"no token", nil, models.TokenExpired, false},
+		{"fresh", &models.Token{IssuedAt: now, ExpiresAt: now.Add(30 * 24 * time.Hour)}, models.TokenValid, true},
+		{"near expiry", &models.Token{IssuedAt: now, ExpiresAt: now.Add(time.Hour)}, models.TokenExpiringSoon, true},
+		{"expired", &models.Token{IssuedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}, models.TokenExpired, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tg := &TelegramApi{
+				yandexApi:   stubYandexApi{},
+				token:       tc.token,
+				expiresSoon: 24 * time.Hour,
+			}
+			assert.Equal(t, tc.state, tg.tokenState())
+			assert.Equal(t, tc.want, tg.isAuthorized())
+		})
+	}
+
+	// источникам без OAuth авторизация не нужна
+	assert.True(t, (&TelegramApi{}).isAuthorized())
+}
+
+// заглушка API Яндекс Диска, которая обновляет токен по refresh токену
+type refreshYandexApi struct {
+	yandexdisk.YandexDiskApi
+	calls int
+}
+
+func (r *refreshYandexApi) RefreshToken(refreshToken string) (*models.Token, error) {
+	r.calls++
+	return &models.Token{Value: "new", RefreshToken: refreshToken, ExpiresAt: time.Now().Add(30 * 24 * time.Hour)}, nil
+}
+
+func TestRefreshExpiredToken(t *testing.T) {
+	api := &refreshYandexApi{}
+	expired := time.Now().Add(-time.Hour)
+	tg := &TelegramApi{
+		store:     storage.NewMemoryStorage(),
+		yandexApi: api,
+		token:     &models.Token{Value: "old", ExpiresAt: expired},
+	}
+	// без refresh токена обновить нечем
+	tg.refreshExpiredToken()
+	assert.Zero(t, api.calls)
+	assert.False(t, tg.isAuthorized())
+
+	tg.token = &models.Token{Value: "old", RefreshToken: "refresh", ExpiresAt: expired}
+	tg.refreshExpiredToken()
+	assert.Equal(t, 1, api.calls)
+	assert.True(t, tg.isAuthorized())
+	saved, err := tg.store.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "new", saved.Value)
+
+	// действующий токен не обновляется
+	tg.refreshExpiredToken()
+	assert.Equal(t, 1, api.calls)
+}
+
+func TestAuthState(t *testing.T) {
+	tg := &TelegramApi{}
+	state, err := tg.beginAuth(10, 1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, state)
+
+	// вторая авторизация не сбрасывает первую
+	_, err = tg.beginAuth(20, 2)
+	assert.ErrorIs(t, err, errorApi.ErrAuthInProgress)
+
+	// код принимается только от запустившего авторизацию в том же чате и только один раз
+	assert.False(t, tg.takeAuthCode(10, &tgbotapi.User{ID: 2}))
+	assert.False(t, tg.takeAuthCode(20, &tgbotapi.User{ID: 1}))
+	assert.False(t, tg.takeAuthCode(10, nil))
+	assert.True(t, tg.takeAuthCode(10, &tgbotapi.User{ID: 1}))
+	assert.False(t, tg.takeAuthCode(10, &tgbotapi.User{ID: 1}))
+	assert.True(t, tg.ConsumeState(state))
+
+	tg.endAuth()
+	_, err = tg.beginAuth(20, 2)
+	assert.NoError(t, err)
+}
+
+func TestMatchFolders(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		folders []string
+		want    bool
+	}{
+		{"no subscriptions", "disk:/a.txt", nil, true},
+		{"root", "disk:/Общее/a.txt", []string{"/"}, true},
+		{"direct child", "disk:/Общее/a.txt", []string{"/Общее"}, true},
+		{"nested", "disk:/Общее/Фото/a.jpg", []string{"/Общее"}, true},
+		{"sibling with same prefix", "disk:/Общее2/a.txt", []string{"/Общее"}, false},
+		{"other folder", "disk:/Личное/a.txt", []string{"/Общее", "/Фото"}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchFolders(tc.path, tc.folders))
+		})
+	}
+}
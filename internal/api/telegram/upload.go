@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/notice"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// файл из сообщения, который нужно загрузить на Диск
+type chatFile struct {
+	id   string // file_id в Telegram
+	name string // имя файла на Диске
+	size int64  // размер, 0 - неизвестен
+}
+
+// функция возвращает документ или самое большое фото из сообщения
+// у фото нет имени, поэтому имя строится из времени отправки
+func fileFromMessage(msg *tgbotapi.Message) (chatFile, bool) {
+	switch {
+	case msg.Document != nil:
+		name := path.Base(strings.ReplaceAll(msg.Document.FileName, "\\", "/"))
+		if name == "" || name == "." || name == "/" {
+			name = "document_" + msg.Time().Format("20060102_150405")
+		}
+		return chatFile{id: msg.Document.FileID, name: name, size: int64(msg.Document.FileSize)}, true
+	case len(msg.Photo) > 0:
+		// Telegram присылает несколько размеров фото, последний - самый большой
+		photo := msg.Photo[len(msg.Photo)-1]
+		name := "photo_" + msg.Time().Format("20060102_150405") + ".jpg"
+		return chatFile{id: photo.FileID, name: name, size: int64(photo.FileSize)}, true
+	}
+	return chatFile{}, false
+}
+
+// метод загружает на Диск документ или фото, отправленные боту
+// загружать могут участники и администраторы
+func (tg *TelegramApi) uploadFromChat(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	file, ok := fileFromMessage(msg)
+	if !ok {
+		return
+	}
+	switch {
+	case tg.yandexApi == nil:
+		tg.sendMsg(chatID, config.RespOnlyCmd)
+		return
+	case !tg.isAuthorized():
+		tg.sendMsg(chatID, config.RespNeedAuth)
+		return
+	}
+	if role := tg.roleOf(msg.From); role < models.RoleMember {
+		tg.denyAccess(chatID, role, models.RoleMember)
+		return
+	}
+	if file.size > tg.uploadMaxSize {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadTooLarge, file.name, notice.HumanSize(tg.uploadMaxSize)))
+		return
+	}
+	folder := tg.chatUploadFolder(chatID)
+	target := models.DiskPrefix + path.Join(folder, file.name)
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadStarted, file.name, folder))
+
+	err := tg.uploadFile(file, target)
+	switch {
+	case errors.Is(err, errorApi.ErrFileExists):
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadExists, models.StripScheme(target)))
+	case err != nil:
+		slog.With(slog.String("path", target), slog.Any("error", err)).Error("upload file from chat failed")
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadFailed, file.name))
+	default:
+		slog.Info(fmt.Sprintf("chat_id: %v; загружен файл %s", chatID, target))
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespUploaded, models.StripScheme(target)))
+	}
+}
+
+// метод скачивает файл из Telegram и передает его на Диск потоком, не сохраняя в памяти
+func (tg *TelegramApi) uploadFile(file chatFile, target string) error {
+	fileURL, err := tg.bot.GetFileDirectURL(file.id)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	resp, err := tg.bot.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, errorApi.ErrInvalidStatusCode)
+	}
+	return tg.yandexApi.Upload(target, resp.Body, resp.ContentLength)
+}
+
+// метод возвращает папку для загрузки файлов чата
+func (tg *TelegramApi) chatUploadFolder(chatID int64) string {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	if folder, ok := tg.uploadFolders[chatID]; ok {
+		return folder
+	}
+	return tg.uploadFolder
+}
+
+// команда /folder [папка]: без аргумента показывает папку для загрузки, с аргументом - меняет ее
+func (tg *TelegramApi) folder(chatID int64, arg string) {
+	if tg.yandexApi == nil {
+		tg.sendMsg(chatID, config.RespUnknownCmd)
+		return
+	}
+	if strings.TrimSpace(arg) == "" {
+		tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadFolder, tg.chatUploadFolder(chatID)))
+		return
+	}
+	folder := models.NormalizeFolder(arg)
+	tg.mu.Lock()
+	// папка по умолчанию не хранится, чтобы смена настройки применялась к чату
+	if folder == tg.uploadFolder {
+		delete(tg.uploadFolders, chatID)
+		folder = ""
+	} else {
+		tg.uploadFolders[chatID] = folder
+	}
+	tg.mu.Unlock()
+	if err := tg.store.SaveUploadFolder(chatID, folder); err != nil {
+		slog.With(slog.Any("error", err)).Error("save upload folder to storage failed")
+	}
+	tg.sendMsg(chatID, fmt.Sprintf(config.RespUploadFolderSet, tg.chatUploadFolder(chatID)))
+}
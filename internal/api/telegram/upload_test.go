@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFromMessage(t *testing.T) {
+	date := int(time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local).Unix())
+
+	file, ok := fileFromMessage(&tgbotapi.Message{
+		Date:     date,
+		Document: &tgbotapi.Document{FileID: "doc", FileName: "../отчет.pdf", FileSize: 100},
+	})
+	require.True(t, ok)
+	assert.Equal(t, chatFile{id: "doc", name: "отчет.pdf", size: 100}, file)
+
+	// берется самый большой размер фото
+	file, ok = fileFromMessage(&tgbotapi.Message{
+		Date:  date,
+		Photo: []tgbotapi.PhotoSize{{FileID: "small", FileSize: 10}, {FileID: "big", FileSize: 1000}},
+	})
+	require.True(t, ok)
+	assert.Equal(t, chatFile{id: "big", name: "photo_20240501_102030.jpg", size: 1000}, file)
+
+	_, ok = fileFromMessage(&tgbotapi.Message{Text: "текст"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,185 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	secretTokenHeader      = "X-Telegram-Bot-Api-Secret-Token" // заголовок с секретом, который Telegram добавляет к апдейтам
+	webhookShutdownTimeout = 5 * time.Second                   // время на завершение обработки апдейтов
+)
+
+// сервер, принимающий апдейты от Telegram вместо long polling
+type webhookServer struct {
+	srv      *http.Server
+	updateCh chan tgbotapi.Update // канал апдейтов, тот же, что читает listenUpdates
+	certFile string               // сертификат TLS, пустой - HTTP за прокси
+	keyFile  string
+}
+
+// обработчик апдейтов, проверяет секрет и передает апдейт в канал
+type webhookHandler struct {
+	secret   string
+	updateCh chan<- tgbotapi.Update
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	// апдейт без верного секрета пришел не от Telegram
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(h.secret)) != 1 {
+		slog.With(slog.String("remote", r.RemoteAddr)).Warn("webhook request with invalid secret token")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		slog.With(slog.Any("error", err)).Error("decode webhook update failed")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	select {
+	case h.updateCh <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+		// Telegram повторит апдейт, если не получит ответ
+	}
+}
+
+// метод регистрирует webhook в Telegram и запускает сервер, возвращает канал апдейтов
+func (tg *TelegramApi) startWebhook(cfg *config.Config) (tgbotapi.UpdatesChannel, error) {
+	// Telegram принимает только https адрес, ошибка в конфиге иначе проявится лишь при регистрации
+	if err := validateWebhookURL(cfg.Webhook.URL); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrSetWebhook, err)
+	}
+	if err := validateWebhookSecret(cfg.Webhook.Secret); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrSetWebhook, err)
+	}
+	secret := cfg.Webhook.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("%w: %w", errorApi.ErrSetWebhook, err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	updateCh := make(chan tgbotapi.Update, tg.bot.Buffer)
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Webhook.Path, &webhookHandler{
+		secret:   secret,
+		updateCh: updateCh,
+	})
+	tg.webhook = &webhookServer{
+		srv: &http.Server{
+			Addr:              cfg.Webhook.Listen,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		updateCh: updateCh,
+		certFile: cfg.Webhook.CertFile,
+		keyFile:  cfg.Webhook.KeyFile,
+	}
+	go tg.webhook.serve()
+
+	// регистрация webhook
+	params := tgbotapi.Params{
+		"url":          cfg.Webhook.URL,
+		"secret_token": secret,
+	}
+	var err error
+	if cfg.Webhook.UploadCert && cfg.Webhook.CertFile != "" {
+		// самоподписанный сертификат необходимо передать в Telegram
+		_, err = tg.bot.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{{
+			Name: "certificate",
+			Data: tgbotapi.FilePath(cfg.Webhook.CertFile),
+		}})
+	} else {
+		_, err = tg.bot.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		tg.webhook.close()
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrSetWebhook, err)
+	}
+	slog.With(
+		slog.String("url", cfg.Webhook.URL),
+		slog.String("listen", cfg.Webhook.Listen),
+	).Info("webhook registered")
+	return updateCh, nil
+}
+
+// функция проверяет, что адрес webhook - абсолютный https адрес
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrWebhookURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: %q", errorApi.ErrWebhookURL, rawURL)
+	}
+	return nil
+}
+
+// функция проверяет секрет webhook по правилам secret_token Telegram
+// пустой секрет допустим, он генерируется при старте; сам секрет в ошибку не попадает
+func validateWebhookSecret(secret string) error {
+	if secret == "" {
+		return nil
+	}
+	if len(secret) > 256 {
+		return fmt.Errorf("%w: length %d", errorApi.ErrWebhookSecret, len(secret))
+	}
+	for _, r := range secret {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return fmt.Errorf("%w: invalid character %q", errorApi.ErrWebhookSecret, r)
+		}
+	}
+	return nil
+}
+
+// метод запускает сервер, блокирующий
+func (s *webhookServer) serve() {
+	var err error
+	if s.certFile != "" {
+		err = s.srv.ListenAndServeTLS(s.certFile, s.keyFile)
+	} else {
+		err = s.srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.With(slog.Any("error", err)).Error("webhook server failed")
+	}
+}
+
+// метод останавливает сервер и закрывает канал апдейтов
+func (s *webhookServer) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	// после Shutdown обработчики больше не пишут в канал
+	close(s.updateCh)
+	return err
+}
+
+// метод удаляет webhook, чтобы бот снова мог получать апдейты через long polling
+func (tg *TelegramApi) deleteWebhook() error {
+	if _, err := tg.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrDeleteWebhook, err)
+	}
+	return nil
+}
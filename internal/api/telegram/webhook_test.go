@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		code   int
+		update bool
+	}{
+		{
+			name:   "valid update",
+			method: http.MethodPost,
+			secret: "secret",
+			body:   `{"update_id":42,"message":{"message_id":1,"text":"/start","chat":{"id":7}}}`,
+			code:   http.StatusOK,
+			update: true,
+		},
+		{
+			name:   "wrong secret",
+			method: http.MethodPost,
+			secret: "other",
+			body:   `{"update_id":42}`,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "no secret",
+			method: http.MethodPost,
+			body:   `{"update_id":42}`,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost,
+			secret: "secret",
+			body:   `{`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "wrong method",
+			method: http.MethodGet,
+			secret: "secret",
+			code:   http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			updateCh := make(chan tgbotapi.Update, 1)
+			h := &webhookHandler{secret: "secret", updateCh: updateCh}
+
+			req := httptest.NewRequest(tc.method, "/telegram/webhook", strings.NewReader(tc.body))
+			if tc.secret != "" {
+				req.Header.Set(secretTokenHeader, tc.secret)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			if !tc.update {
+				assert.Len(t, updateCh, 0)
+				return
+			}
+			update := <-updateCh
+			assert.Equal(t, 42, update.UpdateID)
+			assert.Equal(t, int64(7), update.Message.Chat.ID)
+		})
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.NoError(t, validateWebhookURL("https://bot.example.com/telegram/webhook"))
+	for _, rawURL := range []string{"", "http://bot.example.com/hook", "bot.example.com/hook", "https:///hook", "://bad"} {
+		assert.ErrorIs(t, validateWebhookURL(rawURL), errorApi.ErrWebhookURL, rawURL)
+	}
+}
+
+func TestValidateWebhookSecret(t *testing.T) {
+	for _, secret := range []string{"", "abc", "A-z_09", strings.Repeat("a", 256)} {
+		assert.NoError(t, validateWebhookSecret(secret), secret)
+	}
+	for _, secret := range []string{"with space", "тайна", "a.b", "a+b", strings.Repeat("a", 257)} {
+		assert.ErrorIs(t, validateWebhookSecret(secret), errorApi.ErrWebhookSecret, secret)
+	}
+}
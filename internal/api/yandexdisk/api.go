@@ -2,22 +2,29 @@ package yandexdisk
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VoC925/tgBotNotice/internal/config"
 	"github.com/VoC925/tgBotNotice/internal/errorApi"
 	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/source"
 )
 
 const (
-// таймаут клиента
-// timeoutDefault = 5 * time.Second
+	// таймаут клиента
+	// timeoutDefault = 5 * time.Second
+
+	uploadsLimit = 100 // сколько последних загруженных файлов запрашивается за один опрос
 )
 
 type (
@@ -25,44 +32,80 @@ type (
 	params  map[string]string // мапа параметров запроса
 )
 
+// интерфейс API Яндекс Диска: источник событий с файлами и OAuth авторизация
+// Watch опрашивает API токеном, установленным через SetToken, и параллельно обновляет его до истечения
 type YandexDiskApi interface {
-	Update() <-chan *models.UpdateInfoSlice // метод, который отправляет через канал полученные обновления
-	Close() error                           // метод для закрытия соедиения с API
-	UpdateDiskData(string)                  // метод опрашивающий API
-	Stop()                                  // метод останавливающий отправку уведомлений
-	// авторизация
-	AuthorizeURL() string                            // запросить ссылку для получение кода авторизации
-	RequestToken(code string) (*models.Token, error) // получить токен из кода авторизации
+	source.Source
+	Authenticator
+	Files
+	Browser
+	Quota
+	Poller
+}
+
+// интерфейс состояния опроса API
+type Poller interface {
+	LastPoll() time.Time // время последнего успешного опроса, нулевое время - успешных опросов еще не было
+}
+
+// интерфейс OAuth авторизации Яндекса
+type Authenticator interface {
+	AuthorizeURL(state string) string                        // запросить ссылку для получение кода авторизации, state - nonce для колбэка
+	RequestToken(code string) (*models.Token, error)         // получить токен из кода авторизации
+	RefreshToken(refreshToken string) (*models.Token, error) // получить новый токен по refresh токену
+	TokenRefreshed() <-chan *models.Token                    // канал, в который отправляются обновленные токены
+	SetToken(t *models.Token)                                // заменить токен, которым выполняется опрос API
 }
 
 type yandexDiskAPI struct {
 	clientID      string
 	clientSecret  string
+	redirectURI   string // адрес колбэка, на который Яндекс вернет код авторизации
 	client        *http.Client
-	pauseRequest  time.Duration // период опроса API
-	timeFreshData time.Duration
+	pauseRequest  time.Duration                // период опроса API
+	cursor        *cursor                      // курсор уже отправленных файлов
+	watcher       *watcher                     // отслеживание изменений в папках, nil - если папки не заданы
+	refreshBefore time.Duration                // за сколько до истечения токена его нужно обновить
+	quotaInterval time.Duration                // период проверки заполненности Диска, 0 - проверка отключена
+	quotaCh       chan *models.DiskInfo        // канал для отправки объема Диска
 	updateCh      chan *models.UpdateInfoSlice // канал для отправки обновлений
 	stopCh        chan struct{}                // канал для остановки горутины отправки уведомлений
+	lastPoll      atomic.Int64                 // время последнего успешного опроса в UnixNano, 0 - опросов еще не было
+
+	muToken   sync.RWMutex       // мьютекс для токена
+	token     *models.Token      // текущий access токен, которым выполняются запросы
+	tokenCh   chan *models.Token // канал для отправки обновленных токенов
+	refreshCh chan struct{}      // канал для внепланового обновления токена (например, при ответе 401)
+	resetCh   chan struct{}      // канал, сообщающий фоновому обновлению о замене токена
 }
 
 // конструктор
-func NewYandexDiskAPI() YandexDiskApi {
+// store - хранилище курсора отправленных файлов
+func NewYandexDiskAPI(store CursorStore) YandexDiskApi {
 	cfg := config.ConfigInstance
 	return &yandexDiskAPI{
 		clientID:     cfg.Telegram.ClientID,
 		clientSecret: cfg.Telegram.ClientSecret,
+		redirectURI:  cfg.Server.RedirectURI,
 		client: &http.Client{
 			Timeout: cfg.Api.Timeout,
 		},
 		pauseRequest:  cfg.Telegram.TimePauseRequest,
-		timeFreshData: cfg.Telegram.TimeFreshData,
+		cursor:        newCursor(store),
+		watcher:       newWatcher(cfg.Disk.WatchFolders),
+		refreshBefore: cfg.Telegram.TimeRefreshToken,
+		quotaInterval: quotaInterval(cfg),
+		quotaCh:       make(chan *models.DiskInfo, 1),
 		updateCh:      make(chan *models.UpdateInfoSlice),
 		stopCh:        make(chan struct{}),
+		tokenCh:       make(chan *models.Token, 1),
+		refreshCh:     make(chan struct{}, 1),
+		resetCh:       make(chan struct{}, 1),
 	}
 }
 
 // метод возвращает канал для чтения данных
-func (api *yandexDiskAPI) Update() <-chan *models.UpdateInfoSlice {
+func (api *yandexDiskAPI) Events() <-chan *models.UpdateInfoSlice {
 	return api.updateCh
 }
 
@@ -72,37 +115,40 @@ func (api *yandexDiskAPI) Close() error {
 }
 
 // метод создает URL ссылку для получения кода авторизации
-func (c *yandexDiskAPI) AuthorizeURL() string {
-	p := c.createParams(
-		params{
-			"response_type": "code",
-			"client_id":     fmt.Sprint(c.clientID),
-		},
-	)
-	return fmt.Sprintf("%s?%s", config.AuthorizeURL, p)
+// state - одноразовый nonce, который Яндекс вернет в колбэк вместе с кодом
+func (c *yandexDiskAPI) AuthorizeURL(state string) string {
+	p := params{
+		"response_type": "code",
+		"client_id":     fmt.Sprint(c.clientID),
+		"state":         state,
+	}
+	// если redirect_uri не задан, то Яндекс покажет код на своей странице
+	if c.redirectURI != "" {
+		p["redirect_uri"] = c.redirectURI
+	}
+	return fmt.Sprintf("%s?%s", config.AuthorizeURL, c.createParams(p))
 }
 
 // метод запрашивает токен и добавляет в хранилище
 func (c *yandexDiskAPI) RequestToken(code string) (*models.Token, error) {
-	return c.requestToken(code)
+	return c.requestToken(
+		params{
+			"grant_type": "authorization_code",
+			"code":       code,
+		},
+	)
 }
 
 // метод для получения access токена через запрос
-func (c *yandexDiskAPI) requestToken(code string) (*models.Token, error) {
+// grant - параметры, определяющие способ получения токена
+func (c *yandexDiskAPI) requestToken(grant params) (*models.Token, error) {
+	grant["client_id"] = fmt.Sprint(c.clientID)
+	grant["client_secret"] = c.clientSecret
 	// выполнение запроса
 	resp, err := c.doRequest(
-		http.MethodPost, // метод запроса
-		config.TokenURL, // URL
-		strings.NewReader(
-			c.createParams(
-				params{
-					"grant_type":    "authorization_code",
-					"code":          code,
-					"client_id":     fmt.Sprint(c.clientID),
-					"client_secret": c.clientSecret,
-				},
-			),
-		), // тело запроса
+		http.MethodPost,                          // метод запроса
+		config.TokenURL,                          // URL
+		strings.NewReader(c.createParams(grant)), // тело запроса
 		headers{
 			"Content-type": "application/x-www-form-urlencoded",
 		}, // заголовки
@@ -123,6 +169,13 @@ func (c *yandexDiskAPI) validResponse(resp *http.Response) error {
 	// статус код ответа не 200
 	if resp.StatusCode != http.StatusOK {
 		slog.With(slog.Int("code", resp.StatusCode)).Debug("bad status code response")
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: %w", errorApi.ErrInvalidStatusCode, errorApi.ErrUnauthorized)
+		case http.StatusConflict:
+			return fmt.Errorf("%w: %w", errorApi.ErrInvalidStatusCode, errorApi.ErrFileExists)
+		}
 		return errorApi.ErrInvalidStatusCode
 	}
 	return nil
@@ -130,11 +183,13 @@ func (c *yandexDiskAPI) validResponse(resp *http.Response) error {
 
 // метод для парсинга структуры из ответа запроса
 func (c *yandexDiskAPI) parseTokenInfo(resp *http.Response) (*models.Token, error) {
+	defer resp.Body.Close()
 	tokenInfo := models.Token{}
 	if err := json.NewDecoder(resp.Body).Decode(&tokenInfo); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	// Яндекс возвращает относительное время жизни, поэтому сразу переводим его в абсолютное
+	tokenInfo.SetIssued(time.Now())
 	return &tokenInfo, nil
 }
 
@@ -159,7 +214,15 @@ func (c *yandexDiskAPI) createParams(getParams params) string {
 	return p.Encode()
 }
 
-func (c *yandexDiskAPI) UpdateDiskData(token string) {
+// метод опрашивает API, пока не будет вызван Stop
+func (c *yandexDiskAPI) Watch() {
+	// обновление токена идет параллельно с опросом
+	go c.renewToken(c.stopCh)
+	// проверка заполненности Диска тем же токеном
+	if c.quotaInterval > 0 {
+		go c.watchQuota(c.stopCh)
+	}
+
 	ticker := time.NewTicker(c.pauseRequest)
 	defer ticker.Stop()
 	slog.Debug("запущен тикер с отправкой данных в канал")
@@ -170,60 +233,93 @@ loop:
 		case <-ticker.C:
 			slog.Debug("сработал тикер")
 			// реализация опроса
-			// выполнение запроса
-			resp, err := c.doRequest(
-				http.MethodGet,      // метод запроса
-				config.DiskFilesURL, // URL
-				nil,
-				headers{
-					"Authorization": fmt.Sprintf("OAuth %s", token),
-				}, // заголовки
-			)
-			if err != nil {
-				slog.With(slog.Any("error", err)).Error("request to service failed")
-				continue
-			}
-			// валидность ответа
-			if err := c.validResponse(resp); err != nil {
-				slog.With(slog.Any("error", err)).Error("invalid response from service")
-				continue
-			}
-			// парсим ответ в структуру
-			updateInfo := &models.UpdateInfoSlice{}
-			body := resp.Body
-			if err := json.NewDecoder(body).Decode(&updateInfo); err != nil {
-				slog.With(slog.Any("error", err)).Error("parse request to service failed")
-				body.Close()
-				continue
+			filteredData := c.pollUploads()
+			// события из отслеживаемых папок: изменения, перемещения и удаления
+			if c.watcher != nil {
+				*filteredData = append(*filteredData, c.pollWatcher()...)
 			}
-			body.Close()
-			// отфильтрованные данные, то есть обновления, которые пришли в течение 1 минуты
-			filteredData := c.filter(updateInfo)
 			// если нет новых данных, то выходим и ждем нового запроса к сервису
 			if len(*filteredData) == 0 {
 				slog.Debug("Нет новых данных на Яндекс Диске")
 				continue
 			}
 			// отправляем в канал, если есть что отправлять
-			c.updateCh <- filteredData
-			slog.Debug("Данные отправлены в канал")
+			select {
+			case c.updateCh <- filteredData:
+				slog.Debug("Данные отправлены в канал")
+			case <-c.stopCh:
+				break loop
+			}
 		case <-c.stopCh:
 			slog.Debug("Получен сигнал о закрытии канала stopCh")
 			break loop
 		}
 	}
-	slog.Debug("UpdateDiskData() закрыта, канал ticker закрыт")
+	slog.Debug("Watch() закрыта, канал ticker закрыт")
+}
+
+// метод возвращает время последнего успешного опроса API
+func (c *yandexDiskAPI) LastPoll() time.Time {
+	nanos := c.lastPoll.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// метод запрашивает последние загруженные файлы и возвращает те, о которых еще не было уведомлений
+func (c *yandexDiskAPI) pollUploads() *models.UpdateInfoSlice {
+	// парсим ответ в структуру
+	updateInfo := &models.UpdateInfoSlice{}
+	if err := c.getJSON(config.DiskFilesURL, params{"limit": strconv.Itoa(uploadsLimit)}, updateInfo); err != nil {
+		slog.With(slog.Any("error", err)).Error("request last uploaded files failed")
+		return &models.UpdateInfoSlice{}
+	}
+	c.lastPoll.Store(time.Now().UnixNano())
+	// отфильтрованные данные, то есть файлы, о которых еще не было уведомлений
+	filteredData := c.cursor.filter(updateInfo)
+	// файлы из отслеживаемых папок приходят от watcher, чтобы не дублировать уведомления
+	if c.watcher != nil {
+		filteredData = c.watcher.exclude(filteredData)
+	}
+	return filteredData
+}
+
+// метод выполняет авторизованный GET запрос к API и парсит JSON ответ в dst
+func (c *yandexDiskAPI) getJSON(rawURL string, query params, dst any) error {
+	return c.requestJSON(http.MethodGet, rawURL, query, dst)
 }
 
-func (c *yandexDiskAPI) filter(data *models.UpdateInfoSlice) *models.UpdateInfoSlice {
-	var filteredData models.UpdateInfoSlice
-	timeNow := time.Now().Add(-1 * c.timeFreshData)
-	for _, elem := range *data {
-		if timeNow.Before(elem.CreatedAt) {
-			filteredData = append(filteredData, elem)
+// метод выполняет авторизованный запрос к API и парсит JSON ответ в dst
+func (c *yandexDiskAPI) requestJSON(method, rawURL string, query params, dst any) error {
+	if len(query) != 0 {
+		rawURL = fmt.Sprintf("%s?%s", rawURL, c.createParams(query))
+	}
+	// выполнение запроса
+	resp, err := c.doRequest(
+		method, // метод запроса
+		rawURL, // URL
+		nil,
+		headers{
+			"Authorization": fmt.Sprintf("OAuth %s", c.accessToken()),
+		}, // заголовки
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
+	}
+	// валидность ответа
+	if err := c.validResponse(resp); err != nil {
+		if errors.Is(err, errorApi.ErrUnauthorized) {
+			// токен отозван или истек, пробуем обновить его не дожидаясь срока
+			c.refreshNow()
 		}
+		return fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
 	}
-	return &filteredData
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrUnmarshalJSON, err)
+	}
+	return nil
 }
 
 // метод, который закрывает канал stopCh
@@ -0,0 +1,75 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+const (
+	filesPageLimit = 1000  // количество файлов на одной странице плоского списка
+	findScanLimit  = 20000 // сколько файлов Диска просматривается при поиске
+)
+
+// интерфейс просмотра Диска, методы только читают данные
+type Browser interface {
+	List(path string, offset, limit int) (models.UpdateInfoSlice, int, error) // содержимое папки и общее количество элементов в ней
+	Recent(limit int) (models.UpdateInfoSlice, error)                         // последние загруженные файлы
+	Find(name string, limit int) (models.UpdateInfoSlice, error)              // файлы, в имени которых есть name
+}
+
+// метод возвращает страницу содержимого папки
+func (c *yandexDiskAPI) List(path string, offset, limit int) (models.UpdateInfoSlice, int, error) {
+	list := resourceList{}
+	err := c.getJSON(config.DiskResourcesURL, params{
+		"path":   path,
+		"limit":  strconv.Itoa(limit),
+		"offset": strconv.Itoa(offset),
+	}, &list)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", errorApi.ErrBrowseDisk, err)
+	}
+	return list.Embedded.Items, list.Embedded.Total, nil
+}
+
+// метод возвращает последние загруженные файлы, новые - первыми
+func (c *yandexDiskAPI) Recent(limit int) (models.UpdateInfoSlice, error) {
+	items := models.UpdateInfoSlice{}
+	if err := c.getJSON(config.DiskFilesURL, params{"limit": strconv.Itoa(limit)}, &items); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrBrowseDisk, err)
+	}
+	return items, nil
+}
+
+// метод ищет файлы по части имени без учета регистра
+// у REST API Диска нет поиска, поэтому просматривается плоский список файлов, но не больше findScanLimit
+func (c *yandexDiskAPI) Find(name string, limit int) (models.UpdateInfoSlice, error) {
+	name = strings.ToLower(name)
+	var found models.UpdateInfoSlice
+	for offset := 0; offset < findScanLimit; offset += filesPageLimit {
+		page := models.UpdateInfoSlice{}
+		err := c.getJSON(config.DiskAllFilesURL, params{
+			"limit":  strconv.Itoa(filesPageLimit),
+			"offset": strconv.Itoa(offset),
+		}, &page)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errorApi.ErrBrowseDisk, err)
+		}
+		for _, item := range page {
+			if strings.Contains(strings.ToLower(item.Title), name) {
+				found = append(found, item)
+				if len(found) >= limit {
+					return found, nil
+				}
+			}
+		}
+		if len(page) < filesPageLimit {
+			break
+		}
+	}
+	return found, nil
+}
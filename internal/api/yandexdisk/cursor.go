@@ -0,0 +1,102 @@
+package yandexdisk
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// сколько ключей отправленных файлов помнит курсор
+// история длиннее окна last-uploaded, чтобы после удаления свежих файлов
+// старые файлы, снова попавшие в окно, не считались новыми
+const cursorHistory = 10 * uploadsLimit
+
+// интерфейс хранилища курсора
+type CursorStore interface {
+	Cursor() ([]string, error)
+	SaveCursor(keys []string) error
+}
+
+// курсор, который помнит уже отправленные файлы
+// новым считается файл, ключа которого (путь + md5/resource_id) нет в курсоре,
+// поэтому о каждой загрузке уведомление приходит ровно один раз независимо от периода опроса
+type cursor struct {
+	store  CursorStore
+	keys   []string            // ключи отправленных файлов от новых к старым, не больше cursorHistory
+	seen   map[string]struct{} // те же ключи для быстрого поиска
+	loaded bool                // false - курсора еще нет, первый ответ API только запоминается
+}
+
+// конструктор курсора, восстанавливает ключи из хранилища
+func newCursor(store CursorStore) *cursor {
+	c := &cursor{
+		store: store,
+		seen:  make(map[string]struct{}),
+	}
+	keys, err := store.Cursor()
+	switch {
+	case errors.Is(err, errorApi.ErrCursorNotExist):
+		// первый запуск, курсор будет создан по первому ответу
+	case err != nil:
+		slog.With(slog.Any("error", err)).Error("load cursor failed")
+	default:
+		c.loaded = true
+		c.remember(keys)
+	}
+	return c
+}
+
+// метод возвращает файлы, о которых еще не было уведомлений, и добавляет текущий ответ в историю
+func (c *cursor) filter(data *models.UpdateInfoSlice) *models.UpdateInfoSlice {
+	var (
+		newData models.UpdateInfoSlice
+		current = make(map[string]struct{}, len(*data))
+		keys    = make([]string, 0, len(*data))
+	)
+	for _, elem := range *data {
+		key := elem.Key()
+		if _, ok := current[key]; ok {
+			continue
+		}
+		current[key] = struct{}{}
+		keys = append(keys, key)
+		if _, ok := c.seen[key]; !ok && c.loaded {
+			newData = append(newData, elem)
+		}
+	}
+	if !c.loaded {
+		slog.With(slog.Int("items", len(keys))).Info("курсор создан, существующие файлы не отправляются")
+	}
+	// файлы, которые вышли из окна, остаются в истории: они вернутся в окно, если удалить более свежие
+	for _, key := range c.keys {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	prev, loaded := c.keys, c.loaded
+	c.remember(keys)
+	c.loaded = true
+	// курсор записывается, только если он изменился, чтобы не переписывать хранилище на каждом опросе
+	if loaded && slices.Equal(prev, c.keys) {
+		return &newData
+	}
+	if err := c.store.SaveCursor(c.keys); err != nil {
+		slog.With(slog.Any("error", err)).Error("save cursor failed")
+	}
+	return &newData
+}
+
+// метод заменяет историю курсора ключами keys, самые старые ключи сверх cursorHistory забываются
+func (c *cursor) remember(keys []string) {
+	if len(keys) > cursorHistory {
+		keys = keys[:cursorHistory]
+	}
+	c.keys = keys
+	c.seen = make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		c.seen[key] = struct{}{}
+	}
+}
@@ -0,0 +1,103 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func file(path, md5 string) *models.UpdateInfo {
+	return &models.UpdateInfo{Title: path, Path: path, MD5: md5}
+}
+
+func TestCursorFilter(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	c := newCursor(store)
+
+	// первый ответ только запоминается
+	got := c.filter(&models.UpdateInfoSlice{file("disk:/a.txt", "1")})
+	assert.Empty(t, *got)
+
+	// новый файл отправляется ровно один раз
+	data := &models.UpdateInfoSlice{file("disk:/b.txt", "2"), file("disk:/a.txt", "1")}
+	got = c.filter(data)
+	assert.Equal(t, models.UpdateInfoSlice{file("disk:/b.txt", "2")}, *got)
+	got = c.filter(data)
+	assert.Empty(t, *got)
+
+	// перезапись файла с новым содержимым - это новая загрузка
+	got = c.filter(&models.UpdateInfoSlice{file("disk:/a.txt", "3"), file("disk:/b.txt", "2")})
+	assert.Equal(t, models.UpdateInfoSlice{file("disk:/a.txt", "3")}, *got)
+}
+
+func TestCursorRestoredFromStore(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	data := &models.UpdateInfoSlice{file("disk:/a.txt", "1")}
+	newCursor(store).filter(data)
+
+	// после перезапуска уже отправленные файлы не повторяются, а новые - отправляются
+	c := newCursor(store)
+	got := c.filter(&models.UpdateInfoSlice{file("disk:/b.txt", "2"), file("disk:/a.txt", "1")})
+	assert.Equal(t, models.UpdateInfoSlice{file("disk:/b.txt", "2")}, *got)
+}
+
+func TestCursorDeleteThenSlide(t *testing.T) {
+	c := newCursor(storage.NewMemoryStorage())
+	a, b, cf := file("disk:/a.txt", "1"), file("disk:/b.txt", "2"), file("disk:/c.txt", "3")
+	c.filter(&models.UpdateInfoSlice{b, a})
+
+	// a вышел из окна last-uploaded
+	got := c.filter(&models.UpdateInfoSlice{cf, b})
+	assert.Equal(t, models.UpdateInfoSlice{cf}, *got)
+
+	// c удален, a снова попал в окно, но уже был отправлен
+	got = c.filter(&models.UpdateInfoSlice{b, a})
+	assert.Empty(t, *got)
+}
+
+func TestCursorHistoryLimit(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	c := newCursor(store)
+	c.filter(&models.UpdateInfoSlice{})
+	for i := 0; i < cursorHistory+10; i++ {
+		c.filter(&models.UpdateInfoSlice{file(fmt.Sprintf("disk:/%d.txt", i), "1")})
+	}
+	keys, err := store.Cursor()
+	require.NoError(t, err)
+	assert.Len(t, keys, cursorHistory)
+	// самые новые ключи в начале истории
+	assert.Equal(t, file(fmt.Sprintf("disk:/%d.txt", cursorHistory+9), "1").Key(), keys[0])
+}
+
+// хранилище курсора, которое считает записи
+type countingStore struct {
+	CursorStore
+	saves int
+}
+
+func (s *countingStore) SaveCursor(keys []string) error {
+	s.saves++
+	return s.CursorStore.SaveCursor(keys)
+}
+
+func TestCursorSavedOnlyOnChange(t *testing.T) {
+	store := &countingStore{CursorStore: storage.NewMemoryStorage()}
+	c := newCursor(store)
+	data := &models.UpdateInfoSlice{file("disk:/a.txt", "1")}
+
+	// созданный курсор записывается сразу, даже если файлов нет
+	c.filter(&models.UpdateInfoSlice{})
+	assert.Equal(t, 1, store.saves)
+	c.filter(data)
+	assert.Equal(t, 2, store.saves)
+	// опрос без изменений не переписывает хранилище
+	c.filter(data)
+	c.filter(data)
+	assert.Equal(t, 2, store.saves)
+	c.filter(&models.UpdateInfoSlice{file("disk:/b.txt", "2"), file("disk:/a.txt", "1")})
+	assert.Equal(t, 3, store.saves)
+}
@@ -0,0 +1,138 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+)
+
+// интерфейс работы с файлами на Диске
+type Files interface {
+	Download(path string, limit int64) ([]byte, error)      // скачать файл, если он больше limit - ErrFileTooLarge
+	Preview(previewURL string, limit int64) ([]byte, error) // скачать превью изображения по ссылке из поля preview
+	DownloadURL(path string) (string, error)                // получить прямую ссылку на скачивание файла
+	Publish(path string) (string, error)                    // опубликовать файл и получить публичную ссылку
+	Unpublish(path string) error                            // закрыть доступ к файлу по публичной ссылке
+	Upload(path string, r io.Reader, size int64) error      // загрузить файл, если файл уже есть - ErrFileExists
+}
+
+// метаинформация о ресурсе
+type resource struct {
+	Path      string `json:"path"`
+	PublicURL string `json:"public_url"`
+}
+
+// ссылка, которую возвращает API для операций с ресурсом
+type link struct {
+	Href   string `json:"href"`
+	Method string `json:"method"`
+}
+
+// метод скачивает файл по ссылке из resources/download
+func (c *yandexDiskAPI) Download(path string, limit int64) ([]byte, error) {
+	href, err := c.DownloadURL(path)
+	if err != nil {
+		return nil, err
+	}
+	// ссылка на скачивание уже подписана, токен не нужен
+	data, err := c.getBytes(href, nil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	return data, nil
+}
+
+// метод возвращает прямую ссылку на скачивание файла, ссылка действует ограниченное время
+func (c *yandexDiskAPI) DownloadURL(path string) (string, error) {
+	l := link{}
+	if err := c.getJSON(config.DiskDownloadURL, params{"path": path}, &l); err != nil {
+		return "", fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	return l.Href, nil
+}
+
+// метод публикует файл и возвращает публичную ссылку
+// повторная публикация уже опубликованного файла возвращает ту же ссылку
+func (c *yandexDiskAPI) Publish(path string) (string, error) {
+	if err := c.requestJSON(http.MethodPut, config.DiskPublishURL, params{"path": path}, &link{}); err != nil {
+		return "", fmt.Errorf("%w: %w", errorApi.ErrPublishFile, err)
+	}
+	res := resource{}
+	if err := c.getJSON(config.DiskResourcesURL, params{"path": path, "fields": "path,public_url"}, &res); err != nil {
+		return "", fmt.Errorf("%w: %w", errorApi.ErrPublishFile, err)
+	}
+	return res.PublicURL, nil
+}
+
+// метод закрывает доступ к файлу по публичной ссылке
+func (c *yandexDiskAPI) Unpublish(path string) error {
+	if err := c.requestJSON(http.MethodPut, config.DiskUnpublishURL, params{"path": path}, &link{}); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrUnpublishFile, err)
+	}
+	return nil
+}
+
+// метод загружает файл на Диск: получает ссылку из resources/upload и отправляет по ней содержимое
+// существующий файл не перезаписывается
+func (c *yandexDiskAPI) Upload(path string, r io.Reader, size int64) error {
+	l := link{}
+	if err := c.getJSON(config.DiskUploadURL, params{"path": path, "overwrite": "false"}, &l); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrUploadFile, err)
+	}
+	req, err := http.NewRequest(http.MethodPut, l.Href, r)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrUploadFile, err)
+	}
+	// ссылка на загрузку уже подписана, токен не нужен
+	req.ContentLength = size
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w: %w", errorApi.ErrUploadFile, errorApi.ErrServiceRequest, err)
+	}
+	defer resp.Body.Close()
+	// 201 - файл загружен, 202 - файл принят и еще обрабатывается
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		slog.With(slog.Int("code", resp.StatusCode)).Debug("bad status code response")
+		return fmt.Errorf("%w: %w", errorApi.ErrUploadFile, errorApi.ErrInvalidStatusCode)
+	}
+	return nil
+}
+
+// метод скачивает превью изображения, превью доступно только с токеном
+func (c *yandexDiskAPI) Preview(previewURL string, limit int64) ([]byte, error) {
+	data, err := c.getBytes(previewURL, headers{
+		"Authorization": fmt.Sprintf("OAuth %s", c.accessToken()),
+	}, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrDownloadFile, err)
+	}
+	return data, nil
+}
+
+// метод выполняет GET запрос и читает тело ответа не больше limit байт
+func (c *yandexDiskAPI) getBytes(rawURL string, head headers, limit int64) ([]byte, error) {
+	resp, err := c.doRequest(http.MethodGet, rawURL, nil, head)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
+	}
+	if err := c.validResponse(resp); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength > limit {
+		return nil, errorApi.ErrFileTooLarge
+	}
+	// размер из ответа может отсутствовать, поэтому читаем на байт больше лимита
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, errorApi.ErrFileTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,37 @@
+package yandexdisk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// превью доступно только с токеном
+		if r.Header.Get("Authorization") != "OAuth token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	c := &yandexDiskAPI{
+		client:    srv.Client(),
+		token:     &models.Token{Value: "token"},
+		refreshCh: make(chan struct{}, 1),
+	}
+	data, err := c.Preview(srv.URL, 100)
+	require.NoError(t, err)
+	assert.Len(t, data, 100)
+
+	_, err = c.Preview(srv.URL, 99)
+	assert.ErrorIs(t, err, errorApi.ErrFileTooLarge)
+}
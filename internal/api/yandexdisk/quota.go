@@ -0,0 +1,65 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// интерфейс проверки заполненности Диска
+type Quota interface {
+	DiskInfo() (*models.DiskInfo, error)      // получить объем Диска
+	DiskInfoUpdated() <-chan *models.DiskInfo // канал, в который периодически отправляется объем Диска
+}
+
+// функция возвращает период проверки заполненности Диска, 0 - проверка отключена
+func quotaInterval(cfg *config.Config) time.Duration {
+	if cfg.Quota.Disabled {
+		return 0
+	}
+	return cfg.Quota.CheckInterval
+}
+
+// метод запрашивает объем Диска
+func (c *yandexDiskAPI) DiskInfo() (*models.DiskInfo, error) {
+	info := &models.DiskInfo{}
+	if err := c.getJSON(config.DiskInfoURL, params{"fields": "total_space,used_space,trash_size"}, info); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrDiskInfo, err)
+	}
+	return info, nil
+}
+
+// метод возвращает канал, в который отправляется объем Диска
+func (c *yandexDiskAPI) DiskInfoUpdated() <-chan *models.DiskInfo {
+	return c.quotaCh
+}
+
+// метод периодически запрашивает объем Диска, первая проверка - сразу после запуска
+// stopCh - канал остановки, общий с опросом API
+func (c *yandexDiskAPI) watchQuota(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(c.quotaInterval)
+	defer ticker.Stop()
+	for {
+		info, err := c.DiskInfo()
+		if err != nil {
+			slog.With(slog.Any("error", err)).Error("check disk quota failed")
+		} else {
+			// если предыдущий объем еще не прочитан - заменяем его
+			select {
+			case <-c.quotaCh:
+			default:
+			}
+			c.quotaCh <- info
+		}
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			slog.Debug("проверка заполненности Диска остановлена")
+			return
+		}
+	}
+}
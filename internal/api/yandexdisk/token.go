@@ -0,0 +1,141 @@
+package yandexdisk
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+const (
+	retryRefreshPause = time.Minute // пауза перед повторной попыткой обновить токен
+)
+
+// метод получает новый access токен по refresh токену
+func (c *yandexDiskAPI) RefreshToken(refreshToken string) (*models.Token, error) {
+	if refreshToken == "" {
+		return nil, errorApi.ErrNoRefreshToken
+	}
+	t, err := c.requestToken(
+		params{
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshToken,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Яндекс может не вернуть новый refresh токен, тогда оставляем старый
+	if t.RefreshToken == "" {
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
+
+// метод возвращает канал, в который отправляются обновленные токены
+func (c *yandexDiskAPI) TokenRefreshed() <-chan *models.Token {
+	return c.tokenCh
+}
+
+// метод устанавливает токен, которым выполняются запросы к API
+// если фоновое обновление уже запущено, то оно пересчитывает срок по новому токену
+func (c *yandexDiskAPI) SetToken(t *models.Token) {
+	c.muToken.Lock()
+	c.token = t
+	c.muToken.Unlock()
+	select {
+	case c.resetCh <- struct{}{}:
+	default:
+	}
+}
+
+// метод возвращает текущий access токен
+func (c *yandexDiskAPI) accessToken() string {
+	c.muToken.RLock()
+	defer c.muToken.RUnlock()
+	if c.token == nil {
+		return ""
+	}
+	return c.token.Value
+}
+
+// метод запрашивает внеплановое обновление токена
+func (c *yandexDiskAPI) refreshNow() {
+	select {
+	case c.refreshCh <- struct{}{}:
+	default:
+		// обновление уже запрошено
+	}
+}
+
+// метод в цикле обновляет токен за refreshBefore до его истечения
+// если у токена нет refresh токена, то обновление ждет, пока токен не заменят через SetToken
+// stopCh - канал остановки, общий с опросом API
+func (c *yandexDiskAPI) renewToken(stopCh <-chan struct{}) {
+	slog.Debug("запущено фоновое обновление токена")
+	for {
+		var (
+			wait         time.Duration
+			refreshToken string
+		)
+		c.muToken.RLock()
+		if c.token != nil {
+			wait = time.Until(c.token.ExpiresAt) - c.refreshBefore
+			refreshToken = c.token.RefreshToken
+		}
+		c.muToken.RUnlock()
+
+		if refreshToken == "" {
+			slog.Warn("у токена нет refresh токена, автоматическое обновление невозможно до новой авторизации")
+			select {
+			case <-c.resetCh:
+				continue
+			case <-stopCh:
+				slog.Debug("фоновое обновление токена остановлено")
+				return
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-c.refreshCh:
+			timer.Stop()
+		case <-c.resetCh:
+			// токен заменен, пересчитываем время обновления
+			timer.Stop()
+			continue
+		case <-stopCh:
+			timer.Stop()
+			slog.Debug("фоновое обновление токена остановлено")
+			return
+		}
+
+		t, err := c.RefreshToken(refreshToken)
+		if err != nil {
+			slog.With(slog.Any("error", err)).Error("refresh token failed")
+			// повторяем попытку через паузу
+			select {
+			case <-time.After(retryRefreshPause):
+				continue
+			case <-stopCh:
+				return
+			}
+		}
+		c.SetToken(t)
+		slog.With(slog.Time("expires_at", t.ExpiresAt)).Info("access токен обновлен")
+		// отдаем токен наружу, если предыдущий еще не прочитан - заменяем его
+		select {
+		case c.tokenCh <- t:
+		default:
+			select {
+			case <-c.tokenCh:
+			default:
+			}
+			c.tokenCh <- t
+		}
+	}
+}
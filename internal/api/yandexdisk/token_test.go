@@ -0,0 +1,63 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// транспорт, который отправляет все запросы на тестовый сервер
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestRenewTokenAfterReauth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"new","refresh_token":"%s","expires_in":3600}`, r.FormValue("refresh_token"))
+	}))
+	defer srv.Close()
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	c := &yandexDiskAPI{
+		client:    &http.Client{Transport: redirectTransport{target: target}},
+		token:     &models.Token{Value: "old", ExpiresAt: time.Now().Add(time.Hour)},
+		tokenCh:   make(chan *models.Token, 1),
+		refreshCh: make(chan struct{}, 1),
+		resetCh:   make(chan struct{}, 1),
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.renewToken(stopCh)
+
+	// токен без refresh токена не обновляется, но обновление ждет нового токена
+	select {
+	case <-c.tokenCh:
+		t.Fatal("token without refresh token was renewed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// после повторной авторизации токен обновляется автоматически
+	c.SetToken(&models.Token{Value: "reauth", RefreshToken: "refresh", ExpiresAt: time.Now()})
+	select {
+	case tok := <-c.tokenCh:
+		assert.Equal(t, "new", tok.Value)
+		assert.Equal(t, "refresh", tok.RefreshToken)
+	case <-time.After(time.Second):
+		t.Fatal("token was not renewed after reauth")
+	}
+}
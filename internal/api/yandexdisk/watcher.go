@@ -0,0 +1,104 @@
+package yandexdisk
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/source"
+)
+
+const (
+	listLimit = 100 // количество элементов на одной странице содержимого папки
+)
+
+// ответ API с содержимым папки
+type resourceList struct {
+	Embedded struct {
+		Items []*models.UpdateInfo `json:"items"`
+		Total int                  `json:"total"`
+	} `json:"_embedded"`
+}
+
+// отслеживание изменений в папках через сравнение снимков
+// снимок - все файлы папок с их md5 и временем изменения
+type watcher struct {
+	folders []string      // отслеживаемые папки в виде "/папка"
+	differ  source.Differ // сравнение с предыдущим снимком
+}
+
+// конструктор, если папки не заданы - возвращает nil
+func newWatcher(folders []string) *watcher {
+	if len(folders) == 0 {
+		return nil
+	}
+	w := &watcher{}
+	for _, folder := range folders {
+		w.folders = append(w.folders, models.NormalizeFolder(folder))
+	}
+	return w
+}
+
+// метод убирает из загрузок файлы отслеживаемых папок, так как о них сообщает сам watcher
+func (w *watcher) exclude(data *models.UpdateInfoSlice) *models.UpdateInfoSlice {
+	var filtered models.UpdateInfoSlice
+	for _, elem := range *data {
+		if !w.watches(elem.Path) {
+			filtered = append(filtered, elem)
+		}
+	}
+	return &filtered
+}
+
+// метод проверяет, лежит ли файл в одной из отслеживаемых папок
+func (w *watcher) watches(path string) bool {
+	for _, folder := range w.folders {
+		if models.InFolder(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// метод обходит отслеживаемые папки и возвращает события относительно предыдущего снимка
+func (c *yandexDiskAPI) pollWatcher() models.UpdateInfoSlice {
+	current := make(source.Snapshot)
+	for _, folder := range c.watcher.folders {
+		if err := c.walkFolder(models.DiskPrefix+folder, current); err != nil {
+			// при неполном обходе нельзя сравнивать снимки, иначе появятся ложные удаления
+			slog.With(slog.Any("error", err), slog.String("folder", folder)).Error("walk folder failed")
+			return nil
+		}
+	}
+	return c.watcher.differ.Diff(current)
+}
+
+// метод рекурсивно обходит папку и складывает файлы в files
+func (c *yandexDiskAPI) walkFolder(folder string, files source.Snapshot) error {
+	for offset := 0; ; offset += listLimit {
+		list := resourceList{}
+		err := c.getJSON(config.DiskResourcesURL, params{
+			"path":   folder,
+			"limit":  strconv.Itoa(listLimit),
+			"offset": strconv.Itoa(offset),
+		}, &list)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errorApi.ErrWalkFolder, folder, err)
+		}
+		for _, item := range list.Embedded.Items {
+			if item.Type == "dir" {
+				if err := c.walkFolder(item.Path, files); err != nil {
+					return err
+				}
+				continue
+			}
+			files[item.Path] = item
+		}
+		if offset+listLimit >= list.Embedded.Total {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package yandexdisk
+
+import (
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWatcher(t *testing.T) {
+	assert.Nil(t, newWatcher(nil))
+	w := newWatcher([]string{"/Общее/", "disk:/Фото"})
+	assert.Equal(t, []string{"/Общее", "/Фото"}, w.folders)
+}
+
+func TestWatcherExclude(t *testing.T) {
+	w := newWatcher([]string{"/Общее"})
+	data := &models.UpdateInfoSlice{
+		{Path: "disk:/Общее/a.txt"},
+		{Path: "disk:/Личное/b.txt"},
+	}
+	assert.Equal(t, models.UpdateInfoSlice{{Path: "disk:/Личное/b.txt"}}, *w.exclude(data))
+}
@@ -15,18 +15,89 @@ import (
 type Config struct {
 	Telegram struct {
 		Token            string        `yaml:"token" env-required:"true"`
-		ClientID         string        `yaml:"client_id" env-required:"true"`
-		ClientSecret     string        `yaml:"client_secret" env-required:"true"`
+		ClientID         string        `yaml:"client_id"`     // приложение Яндекса, обязательно только для источника yandex
+		ClientSecret     string        `yaml:"client_secret"` // пароль приложения Яндекса, обязательно только для источника yandex
 		TimePauseRequest time.Duration `yaml:"time_pause_request" env-default:"60s"`
-		TimeFreshData    time.Duration `yaml:"time_fresh_data" env-default:"60s"`
+		TimeRefreshToken time.Duration `yaml:"time_refresh_token" env-default:"24h"` // за сколько до истечения обновлять токен
 		TimeoutUpdate    int           `yaml:"timeout_update" env-default:"60s"`
 		Offset           int           `yaml:"offset" env-default:"0"`
 		IsDebug          bool          `yaml:"is_debug" env-default:"false"`
-		Admin            string        `yaml:"admin" env-required:"true"`
+		OwnerID          int64         `yaml:"owner_id"` // user ID владельца, пустой - владелец назначается командой /claim
+		Admins           []int64       `yaml:"admins"`   // user ID администраторов
 	} `yaml:"telegram"`
 	Api struct {
 		Timeout time.Duration `yaml:"timeout" env-default:"30s"`
 	} `yaml:"api"`
+	Notice struct {
+		ParseMode string `yaml:"parse_mode" env-default:"HTML"` // режим разметки: HTML, MarkdownV2 или пустой - обычный текст
+		Template  string `yaml:"template"`                      // шаблон одного файла (text/template), пустой - шаблон по умолчанию
+	} `yaml:"notice"`
+	Media struct {
+		TextOnly     bool  `yaml:"text_only" env-default:"false"`         // отправлять только текстовые уведомления, без фото и документов
+		MaxSize      int64 `yaml:"max_size" env-default:"10485760"`       // максимальный размер файла в байтах, больше - текстовое уведомление
+		BatchMaxSize int64 `yaml:"batch_max_size" env-default:"52428800"` // сколько байт вложений скачивается за один опрос, остальные файлы - текстом
+	} `yaml:"media"`
+	Queue struct {
+		Size         int           `yaml:"size" env-default:"1000"`         // размер очереди исходящих сообщений
+		GlobalRate   int           `yaml:"global_rate" env-default:"30"`    // сообщений в секунду во все чаты
+		ChatInterval time.Duration `yaml:"chat_interval" env-default:"1s"`  // минимальный интервал между сообщениями в один чат
+		MaxAttempts  int           `yaml:"max_attempts" env-default:"5"`    // попыток отправки до записи в недоставленные
+		BackoffBase  time.Duration `yaml:"backoff_base" env-default:"1s"`   // начальная пауза при ошибке сервера
+		BackoffMax   time.Duration `yaml:"backoff_max" env-default:"1m"`    // максимальная пауза при ошибке сервера
+		DrainTimeout time.Duration `yaml:"drain_timeout" env-default:"10s"` // время на отправку оставшихся сообщений при остановке
+	} `yaml:"queue"`
+	Webhook struct {
+		Enabled    bool   `yaml:"enabled" env-default:"false"`          // получать апдейты через webhook вместо long polling
+		URL        string `yaml:"url"`                                  // публичный https адрес, который регистрируется в Telegram
+		Listen     string `yaml:"listen" env-default:":8443"`           // адрес, на котором слушает сервер
+		Path       string `yaml:"path" env-default:"/telegram/webhook"` // путь, на который приходят апдейты
+		Secret     string `yaml:"secret"`                               // секретный токен: 1-256 символов A-Z, a-z, 0-9, _ и -, пустой - генерируется при старте
+		CertFile   string `yaml:"cert_file"`                            // сертификат TLS, пустой - HTTP за прокси
+		KeyFile    string `yaml:"key_file"`                             // ключ TLS
+		UploadCert bool   `yaml:"upload_cert" env-default:"false"`      // передать самоподписанный сертификат в Telegram
+	} `yaml:"webhook"`
+	Server struct {
+		Enabled      bool   `yaml:"enabled" env-default:"false"`                 // запускать ли сервер для OAuth колбэка
+		Host         string `yaml:"host" env-default:"localhost"`                // адрес сервера
+		Port         int    `yaml:"port" env-default:"8080"`                     // порт сервера
+		CallbackPath string `yaml:"callback_path" env-default:"/oauth/callback"` // путь колбэка
+		RedirectURI  string `yaml:"redirect_uri"`                                // внешний адрес колбэка, указанный в приложении Яндекса
+	} `yaml:"server"`
+	Source struct {
+		Type  string `yaml:"type" env-default:"yandex"` // источник файлов: yandex, local, webdav, s3
+		Local struct {
+			Folders []string `yaml:"folders"` // отслеживаемые папки локальной файловой системы
+		} `yaml:"local"`
+		WebDAV struct {
+			URL      string   `yaml:"url"`      // адрес WebDAV, например https://webdav.yandex.ru
+			Username string   `yaml:"username"` // логин для Basic авторизации
+			Password string   `yaml:"password"` // пароль (для Яндекса - пароль приложения)
+			Folders  []string `yaml:"folders"`  // отслеживаемые папки
+		} `yaml:"webdav"`
+		S3 struct {
+			Endpoint  string   `yaml:"endpoint"`                       // адрес хранилища, например http://localhost:9000
+			Region    string   `yaml:"region" env-default:"us-east-1"` // регион для подписи запросов
+			Bucket    string   `yaml:"bucket"`                         // бакет
+			AccessKey string   `yaml:"access_key"`                     // ключ доступа
+			SecretKey string   `yaml:"secret_key"`                     // секретный ключ
+			Prefixes  []string `yaml:"prefixes"`                       // отслеживаемые префиксы, пусто - весь бакет
+			PathStyle bool     `yaml:"path_style" env-default:"true"`  // адрес вида endpoint/bucket (MinIO)
+		} `yaml:"s3"`
+	} `yaml:"source"`
+	Disk struct {
+		WatchFolders  []string `yaml:"watch_folders"`                          // папки, в которых отслеживаются изменения, перемещения и удаления
+		UploadFolder  string   `yaml:"upload_folder" env-default:"/Telegram"`  // папка для файлов, отправленных боту, если чат не выбрал свою
+		UploadMaxSize int64    `yaml:"upload_max_size" env-default:"20971520"` // максимальный размер файла из чата, Telegram отдает ботам файлы до 20 МБ
+	} `yaml:"disk"`
+	Quota struct {
+		Disabled      bool          `yaml:"disabled" env-default:"false"`      // не проверять заполненность Диска
+		CheckInterval time.Duration `yaml:"check_interval" env-default:"1h"`   // период проверки заполненности Диска
+		Thresholds    []int         `yaml:"thresholds" env-default:"80,90,95"` // проценты заполненности, при достижении которых админу приходит предупреждение
+	} `yaml:"quota"`
+	Storage struct {
+		Type string `yaml:"type" env-default:"file"`       // тип хранилища: file, memory
+		Path string `yaml:"path" env-default:"state.json"` // путь до файла хранилища
+	} `yaml:"storage"`
 	IsDebug bool `yaml:"is_debug" env-default:"false"`
 }
 
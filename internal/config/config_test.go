@@ -20,9 +20,51 @@ func TestXxx(t *testing.T) {
 	assert.Equal(t, cfg.Telegram.ClientSecret, "client_secret_test")
 	assert.Equal(t, cfg.Telegram.TimePauseRequest, time.Duration(time.Second*40))
 	assert.Equal(t, cfg.Telegram.TimeoutUpdate, 59)
-	assert.Equal(t, cfg.Telegram.TimeFreshData, time.Duration(time.Second*24))
 	assert.Equal(t, cfg.Telegram.Offset, 0)
 	assert.Equal(t, cfg.Telegram.IsDebug, true)
+	assert.Equal(t, cfg.Telegram.OwnerID, int64(111))
+	assert.Equal(t, cfg.Telegram.Admins, []int64{222, 333})
 	assert.Equal(t, cfg.Api.Timeout, time.Duration(time.Second*20))
+	assert.Equal(t, cfg.Notice.ParseMode, "MarkdownV2")
+	assert.Equal(t, cfg.Notice.Template, "{{.Index}} {{.Title}}")
+	assert.Equal(t, cfg.Media.TextOnly, true)
+	assert.Equal(t, cfg.Media.MaxSize, int64(1048576))
+	assert.Equal(t, cfg.Queue.Size, 500)
+	assert.Equal(t, cfg.Queue.GlobalRate, 20)
+	assert.Equal(t, cfg.Queue.ChatInterval, 2*time.Second)
+	assert.Equal(t, cfg.Queue.MaxAttempts, 3)
+	assert.Equal(t, cfg.Queue.BackoffBase, time.Second)
+	assert.Equal(t, cfg.Queue.BackoffMax, time.Minute)
+	assert.Equal(t, cfg.Webhook.Enabled, true)
+	assert.Equal(t, cfg.Webhook.URL, "https://bot.example.com/telegram/webhook")
+	assert.Equal(t, cfg.Webhook.Listen, ":8443")
+	assert.Equal(t, cfg.Webhook.Path, "/telegram/webhook")
+	assert.Equal(t, cfg.Webhook.Secret, "secret_test")
+	assert.Equal(t, cfg.Server.Enabled, true)
+	assert.Equal(t, cfg.Server.Host, "localhost")
+	assert.Equal(t, cfg.Server.Port, 9023)
+	assert.Equal(t, cfg.Server.CallbackPath, "/callback")
+	assert.Equal(t, cfg.Server.RedirectURI, "http://localhost:9023/callback")
+	assert.Equal(t, cfg.Source.Type, "local")
+	assert.Equal(t, cfg.Source.Local.Folders, []string{"/mnt/nas"})
+	assert.Equal(t, cfg.Source.WebDAV.URL, "https://webdav.yandex.ru")
+	assert.Equal(t, cfg.Source.WebDAV.Username, "user_test")
+	assert.Equal(t, cfg.Source.WebDAV.Password, "password_test")
+	assert.Equal(t, cfg.Source.WebDAV.Folders, []string{"/Общее"})
+	assert.Equal(t, cfg.Source.S3.Endpoint, "http://localhost:9000")
+	assert.Equal(t, cfg.Source.S3.Region, "us-east-1")
+	assert.Equal(t, cfg.Source.S3.Bucket, "bucket_test")
+	assert.Equal(t, cfg.Source.S3.AccessKey, "access_test")
+	assert.Equal(t, cfg.Source.S3.SecretKey, "secret_test")
+	assert.Equal(t, cfg.Source.S3.Prefixes, []string{"uploads/"})
+	assert.Equal(t, cfg.Source.S3.PathStyle, true)
+	assert.Equal(t, cfg.Disk.WatchFolders, []string{"/Общее"})
+	assert.Equal(t, cfg.Disk.UploadFolder, "/Входящие")
+	assert.Equal(t, cfg.Disk.UploadMaxSize, int64(20971520))
+	assert.Equal(t, cfg.Quota.Disabled, false)
+	assert.Equal(t, cfg.Quota.CheckInterval, 30*time.Minute)
+	assert.Equal(t, cfg.Quota.Thresholds, []int{70, 90})
+	assert.Equal(t, cfg.Storage.Type, "memory")
+	assert.Equal(t, cfg.Storage.Path, "state_test.json")
 	assert.Equal(t, cfg.IsDebug, true)
 }
@@ -2,13 +2,34 @@ package config
 
 const (
 	// команды для всех
-	InfoCmd    = "info"
-	AuthCmd    = "auth"     // авторизация
-	SendCmd    = "send"     // запуск бота
-	StopCmd    = "stop"     // остановка отправки уведомлений бота
-	SpecialCmd = "business" // пасхалка-команда
+	InfoCmd        = "info"
+	AuthCmd        = "auth"        // авторизация
+	SendCmd        = "send"        // запуск бота
+	StopCmd        = "stop"        // остановка отправки уведомлений бота
+	SubscribeCmd   = "subscribe"   // подписка на папку
+	UnsubscribeCmd = "unsubscribe" // отписка от папки
+	UnmuteCmd      = "unmute"      // вернуть уведомления из скрытой папки
+	ShareCmd       = "share"       // опубликовать файл и получить публичную ссылку
+	UnshareCmd     = "unshare"     // закрыть доступ к файлу по публичной ссылке
+	FolderCmd      = "folder"      // папка для загрузки файлов из чата
+	LsCmd          = "ls"          // содержимое папки Диска
+	FindCmd        = "find"        // поиск файлов по имени
+	RecentCmd      = "recent"      // последние загруженные файлы
+	QuotaCmd       = "quota"       // заполненность Диска
+	StatusCmd      = "status"      // состояние уведомлений чата и опроса Диска
+	RequestCmd     = "request"     // запросить доступ к боту
+	ClaimCmd       = "claim"       // стать владельцем бота по секрету из журнала
+	SpecialCmd     = "business"    // пасхалка-команда
 	// команды для админа
-	DeleteListeners = "delete" // удалить всех слушателей, кроме самого админа
+	DeleteListeners = "delete"    // удалить всех слушателей, кроме самого админа
+	MembersCmd      = "members"   // пользователи с доступом к боту
+	RoleCmd         = "role"      // изменить роль пользователя, только для владельца
+	BroadcastCmd    = "broadcast" // сообщение всем слушателям
+	NotifyCmd       = "notify"    // сообщение в один чат
+	ListenersCmd    = "listeners" // список слушателей
+	KickCmd         = "kick"      // удалить слушателя
+	PauseCmd        = "pause"     // приостановить уведомления в чат
+	ResumeCmd       = "resume"    // возобновить уведомления в чат
 	// состояния авторизации
 	NotAuthorized Auth = iota
 	Authorized
@@ -20,7 +41,20 @@ const (
 Для начала работы необходимо сначала авторизоваться через команду /%s.
 Получение уведомлений можно начать командой /%s.
 Если вы хотите приостановить получение уведомлений воспользуйтесь командой /%s,
-аналогично, при запуске уведомлений - /%s.`
+аналогично, при запуске уведомлений - /%s.
+Чтобы получать уведомления только из определенной папки, воспользуйтесь командой /%s <папка>,
+отменить подписку - /%s <папка>.
+Под уведомлениями есть кнопки: публичная ссылка на файл, скачивание файла и скрытие папки.
+Вернуть уведомления из скрытой папки - /%s <папка>.
+Администратор может поделиться файлом по публичной ссылке командой /%s <путь>, закрыть доступ - /%s <путь>.
+Документы и фото, отправленные боту, загружаются на Диск. Посмотреть или сменить папку для загрузки - /%s <папка>.
+Просмотр Диска: содержимое папки - /%s <папка>, поиск по имени - /%s <имя>, последние загруженные файлы - /%s [количество].
+Заполненность Диска - /%s.
+Состояние уведомлений чата, опроса Диска и авторизации - /%s.
+Доступ к боту выдает администратор, запросить доступ - /%s.
+Администратор может посмотреть пользователей с доступом - /%s, владелец - изменить роль пользователя: /%s <user_id> <роль>.
+Администратор может отправить сообщение всем слушателям - /%s <текст> или одному чату - /%s <chat_id|@группа> <текст>.
+Слушатели чатов для администратора: список - /%s, удалить чат - /%s <chat_id>, приостановить уведомления - /%s <chat_id>, возобновить - /%s <chat_id>.`
 	RespStart             = "Чтение уведомлений успешно запущено"
 	RespStop              = "Отправка уведомлений отключена"
 	RespStartedAlready    = "Чтение уведомлений уже было запущено"
@@ -30,22 +64,171 @@ const (
 	RespLetsAuth          = "Для начала работы с сервисом необходимо перейти по ссылке ниже"
 	RespNeedAuth          = "Для начала работы с сервисом необходимо авторизоваться администратору"
 	RespSendCode          = "Введите код, полученный при переходе по ссылке (код действителен 10 минут)"
-	RespAuthorizedAlready = "Вы уже авторизованы, администратор"
+	RespWaitCallback      = "После подтверждения доступа авторизация завершится автоматически. Если Яндекс показал код, введите его сюда (код действителен 10 минут)"
+	RespAuthorizedAlready = "Вы уже авторизованы, администратор. Токен действует до %s"
+	RespTokenExpiringSoon = "Токен истекает %s, необходимо авторизоваться повторно"
+	RespAuthTimeout       = "Время ожидания кода истекло, выполните команду снова"
+	RespAuthInProgress    = "Авторизация уже запущена, дождитесь ее завершения или истечения кода (10 минут)"
+	RespAuthNotRequired   = "Текущему источнику файлов авторизация не требуется"
 	RespAuthFail          = "Произошла ошибка авторизации попробуйте снова"
 	RespAuthSuccess       = "Авторизация прошла успешно"
 	RespTokenFail         = "Возникла внутренняя ошибка. Попробуйте выполнить команду снова"
 	RespListenerExist     = "Бот уже был запущен ранее"
 	RespOnlyAdmin         = "Команда доступна только для администратора"
-	RespStopedFirstly     = "Невозможно остановить чтение уведомлений, пока процесс чтения не был запущен"
+	RespOnlyOwner         = "Команда доступна только владельцу бота"
+	RespOwnerClaimed      = "Вы стали владельцем бота"
+	RespOwnerExists       = "Владелец бота уже назначен"
+	RespClaimFailed       = "Неверный секрет"
+	RespClaimPrivate      = "Команду /%s можно отправить только в личном чате с ботом"
+	RespNoAccess          = "Нет доступа к боту. Запросить доступ у администратора - /%s"
+	RespAccessRequested   = "Запрос доступа отправлен администраторам"
+	RespAccessPending     = "Запрос доступа уже отправлен, дождитесь решения администратора"
+	RespAccessGranted     = "Доступ уже есть, ваша роль: %s"
+	RespAccessRequest     = "%s (id %d) запрашивает доступ к боту"
+	RespAccessApproved    = "Доступ к боту одобрен, начните с команды /%s"
+	RespAccessRejected    = "Запрос доступа к боту отклонен"
+	RespRequestApproved   = "Доступ для %s одобрен"
+	RespRequestRejected   = "Запрос %s отклонен"
+	RespRequestHandled    = "Запрос уже рассмотрен"
+	RespMembers           = "Пользователи с доступом:\n%s"
+	RespNoMembers         = "Пользователей с доступом нет"
+	RespRoleUsage         = "Укажите пользователя и роль, например: /%s 123456789 admin (роли: member, admin, guest - отозвать доступ)"
+	RespRoleSet           = "Роль пользователя %d: %s"
+	RespBroadcastUsage    = "Укажите текст, например: /%s Плановые работы в 20:00"
+	RespNotifyUsage       = "Укажите чат и текст, например: /%s -1001234567890 Текст или /%s @группа Текст"
+	RespChatNotFound      = "Чат %s не найден, бот должен быть его участником"
+	RespNoActiveListeners = "Нет слушателей, которым можно отправить сообщение"
+	RespBroadcastPreview  = "Сообщение будет отправлено %s:\n\n%s"
+	RespBroadcastCanceled = "Отправка отменена"
+	RespBroadcastStarted  = "Отправка началась, получателей: %d"
+	RespBroadcastReport   = "Отправка завершена: доставлено %d, не доставлено %d"
+	RespListeners         = "Слушатели, %d-%d из %d:\n%s"
+	RespNoListeners       = "Слушателей нет"
+	RespListenersDeleted  = "Удалено слушателей: %d"
+	RespNeedChatID        = "Укажите chat_id из списка /%s, например: /%s -1001234567890"
+	RespListenerNotFound  = "Чат %d не найден среди слушателей"
+	RespListenerKicked    = "Чат %d удален из слушателей"
+	RespListenerPaused    = "Уведомления в чат %d приостановлены"
+	RespListenerResumed   = "Уведомления в чат %d возобновлены"
+	RespPausedAlready     = "Уведомления в чат %d уже приостановлены"
+	RespResumedAlready    = "Чат %d уже читает уведомления"
+	// получатели в предпросмотре сообщения
+	TargetListeners        = "всем слушателям (%d)"
+	TargetChat             = "в чат %s"
+	RespStopedFirstly      = "Невозможно остановить чтение уведомлений, пока процесс чтения не был запущен"
+	RespSubscribed         = "Подписка на папку %s оформлена"
+	RespSubscribedAlready  = "Вы уже подписаны на папку %s"
+	RespUnsubscribed       = "Подписка на папку %s отменена"
+	RespNotSubscribed      = "Подписки на папку %s нет"
+	RespNeedFolder         = "Укажите папку, например: /%s /Общее"
+	RespSubscriptions      = "Уведомления приходят только из папок:\n%s"
+	RespNoSubscriptions    = "Подписок на папки нет, уведомления приходят обо всех файлах"
+	RespFolderMuted        = "Уведомления из папки %s скрыты"
+	RespFolderMutedAlready = "Уведомления из папки %s уже скрыты"
+	RespFolderUnmuted      = "Уведомления из папки %s снова приходят"
+	RespFolderNotMuted     = "Папка %s не скрыта"
+	RespMutes              = "Скрыты уведомления из папок:\n%s"
+	RespNoMutes            = "Скрытых папок нет"
+	RespPublicLink         = "Публичная ссылка на %s:\n%s"
+	RespUnpublished        = "Доступ к %s по публичной ссылке закрыт"
+	RespNeedPath           = "Укажите путь до файла, например: /%s /Общее/отчет.docx"
+	RespDownloadLink       = "Файл %s больше лимита отправки, скачать его можно по ссылке (действует несколько часов):\n%s"
+	RespDownloadStarted    = "Файл отправляется"
+	RespActionExpired      = "Кнопка устарела, дождитесь нового уведомления"
+	RespActionFailed       = "Не удалось выполнить действие, попробуйте позже"
+	RespUploadStarted      = "Файл %s загружается в папку %s"
+	RespUploaded           = "Файл загружен: %s"
+	RespUploadExists       = "Файл %s уже есть на Диске, переименуйте файл и отправьте снова"
+	RespUploadTooLarge     = "Файл %s больше лимита загрузки %s"
+	RespUploadFailed       = "Не удалось загрузить файл %s, попробуйте позже"
+	RespUploadFolder       = "Файлы из чата загружаются в папку %s"
+	RespUploadFolderSet    = "Файлы из чата теперь загружаются в папку %s"
+	RespList               = "Папка %s, %d-%d из %d:\n%s"
+	RespListEmpty          = "Папка %s пуста"
+	RespFound              = "Найдено по запросу «%s», %d-%d из %d:\n%s"
+	RespNotFound           = "По запросу «%s» ничего не найдено"
+	RespNeedName           = "Укажите часть имени файла, например: /%s отчет"
+	RespRecent             = "Последние загруженные файлы, %d-%d из %d:\n%s"
+	RespNoRecent           = "Загруженных файлов нет"
+	RespNeedCount          = "Укажите количество файлов от 1 до %d, например: /%s 20"
+	RespQuota              = "Занято %s из %s (%.1f%%), свободно %s, в корзине %s"
+	RespQuotaAlert         = "Диск заполнен на %.1f%% (порог %d%%): занято %s из %s, в корзине %s. Освободите место, иначе загрузка файлов перестанет работать"
+	RespStatus             = "Уведомления: %s\nФильтры: %s\nПоследний успешный опрос Диска: %s\nПоследнее уведомление: %s\nАвторизация на Диске: %s"
+	// значения в ответе на /status
+	StatusNotListening = "не запущены, начать - /%s"
+	StatusNever        = "еще не было"
+	StatusNotice       = "%s, %s" // время доставки и имя файла
+	StatusNoDisk       = "не используется, источник файлов - не Яндекс Диск"
+	StatusTokenValid   = "токен действует до %s"
+	StatusTokenSoon    = "токен истекает %s, администратору нужно авторизоваться повторно"
+	StatusTokenExpired = "токена нет или он истек, администратору нужно авторизоваться"
+	// строка списка пользователей: user ID, имя, роль
+	MemberLine = "%d %s - %s"
+	// строка списка слушателей: название, тип, состояние, фильтры, время последней доставки
+	ListenerLine = "%s, %s, %s\n    фильтры: %s; доставлено: %s"
+	// значения в списке слушателей
+	ListenerOn      = "читает"
+	ListenerOff     = "на паузе"
+	AllFolders      = "все папки"
+	MutedFolders    = "скрыты %s"
+	NeverDelivered  = "еще не было"
+	ChatPrivate     = "личный чат"
+	ChatGroup       = "группа"
+	ChatSupergroup  = "супергруппа"
+	ChatChannel     = "канал"
+	ChatTypeUnknown = "тип неизвестен"
+	// строки списков файлов
+	ListDir  = "📁 %s/"
+	ListFile = "📄 %s"
+	ListSize = "📄 %s, %s"
+	// кнопки под уведомлениями
+	BtnPublicLink = "🔗 Ссылка"
+	BtnDownload   = "⬇️ Скачать"
+	BtnMute       = "🔕 Скрыть папку"
+	BtnUnmute     = "🔔 Вернуть папку"
+	BtnUnshare    = "🚫 Закрыть доступ"
+	BtnApprove    = "✅ Одобрить"
+	BtnReject     = "❌ Отклонить"
+	BtnSend       = "✅ Отправить"
+	BtnCancel     = "✖️ Отмена"
+	BtnPrev       = "« Назад"
+	BtnNext       = "Вперед »"
+	BtnIndex      = "%d. %s" // номер файла перед названием кнопки, если в уведомлении несколько файлов
 	// ссылки
-	FeatureURL   = `https://www.youtube.com/watch?v=WR9mvNa6FDM#access_token=y0_AgAAAAAIYxaZAAwb5AAAAAEKnHJQAAAasOKqKaZCoLE_95VxCuFIyRKhVQ&token_type=bearer&expires_in=31368557&cid=ahnwb0r94k5uavpykpndj4upc8`
-	AuthorizeURL = `https://oauth.yandex.ru/authorize` // url для получение OAuth токена, параметр - значение client_id
-	TokenURL     = `https://oauth.yandex.ru/token`
-	DiskFilesURL = `https://cloud-api.yandex.net/v1/disk/resources/last-uploaded`
-	// ответ пользователю
-	UpdateResponseTemplate = `	Название: "%s" 
-	Дата добавления: %s
-	Путь: "%s"`
+	FeatureURL       = `https://www.youtube.com/watch?v=WR9mvNa6FDM#access_token=y0_AgAAAAAIYxaZAAwb5AAAAAEKnHJQAAAasOKqKaZCoLE_95VxCuFIyRKhVQ&token_type=bearer&expires_in=31368557&cid=ahnwb0r94k5uavpykpndj4upc8`
+	AuthorizeURL     = `https://oauth.yandex.ru/authorize` // url для получение OAuth токена, параметр - значение client_id
+	TokenURL         = `https://oauth.yandex.ru/token`
+	DiskFilesURL     = `https://cloud-api.yandex.net/v1/disk/resources/last-uploaded`
+	DiskResourcesURL = `https://cloud-api.yandex.net/v1/disk/resources`           // содержимое папки, параметр - path
+	DiskDownloadURL  = `https://cloud-api.yandex.net/v1/disk/resources/download`  // ссылка на скачивание файла, параметр - path
+	DiskPublishURL   = `https://cloud-api.yandex.net/v1/disk/resources/publish`   // публикация ресурса, параметр - path
+	DiskUnpublishURL = `https://cloud-api.yandex.net/v1/disk/resources/unpublish` // закрытие публичного доступа, параметр - path
+	DiskAllFilesURL  = `https://cloud-api.yandex.net/v1/disk/resources/files`     // плоский список всех файлов, параметры - limit, offset
+	DiskUploadURL    = `https://cloud-api.yandex.net/v1/disk/resources/upload`    // ссылка для загрузки файла, параметры - path, overwrite
+	DiskInfoURL      = `https://cloud-api.yandex.net/v1/disk`                     // объем Диска: total_space, used_space, trash_size
+	DiskWebURL       = `https://disk.yandex.ru/client/disk`                       // веб-интерфейс Яндекс Диска
+	// шаблоны уведомления о файле (text/template), значения полей уже экранированы под parse_mode
+	NoticeTemplatePlain = `{{.Index}}) {{.Event}}
+	Название: "{{.Title}}"
+	Дата: {{.Date}}
+	Путь: "{{.Path}}"{{if .OldPath}}
+	Прежний путь: "{{.OldPath}}"{{end}}{{if .Size}}
+	Размер: {{.Size}}{{end}}{{if .Link}}
+	Открыть: {{.Link}}{{end}}`
+	NoticeTemplateHTML = `{{.Index}}) <b>{{.Event}}</b>{{if .Hashtag}} {{.Hashtag}}{{end}}
+{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}<b>{{.Title}}</b>{{end}}
+Дата: {{.Date}}
+Путь: <code>{{.Path}}</code>{{if .OldPath}}
+Прежний путь: <code>{{.OldPath}}</code>{{end}}{{if .Size}}
+Размер: {{.Size}}{{end}}{{if .MimeType}}
+Тип: {{.MimeType}}{{end}}`
+	NoticeTemplateMarkdownV2 = `{{.Index}}\) *{{.Event}}*{{if .Hashtag}} {{.Hashtag}}{{end}}
+{{if .Link}}[{{.Title}}]({{.Link}}){{else}}*{{.Title}}*{{end}}
+Дата: {{.Date}}
+Путь: ` + "`{{.Path}}`" + `{{if .OldPath}}
+Прежний путь: ` + "`{{.OldPath}}`" + `{{end}}{{if .Size}}
+Размер: {{.Size}}{{end}}{{if .MimeType}}
+Тип: {{.MimeType}}{{end}}`
 )
 
 type Auth int
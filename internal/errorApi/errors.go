@@ -7,22 +7,57 @@ var (
 	ErrLoadENV  = errors.New("load .env failed")
 	ErrParseCfg = errors.New("parse config failed")
 	// bot
-	ErrCreateBotApi = errors.New("couldn't create bot api")
-	ErrSendMessage  = errors.New("couldn't send message")
-	ErrStartAgain   = errors.New("bot started already")
-	ErrStop         = errors.New("bot stop failed")
-	ErrCtxDeadline  = errors.New("deadline handlind exceeded")
-	ErrNoListener   = errors.New("listener doesn't exist")
+	ErrCreateBotApi  = errors.New("couldn't create bot api")
+	ErrSendMessage   = errors.New("couldn't send message")
+	ErrStartAgain    = errors.New("bot started already")
+	ErrStop          = errors.New("bot stop failed")
+	ErrCtxDeadline   = errors.New("deadline handlind exceeded")
+	ErrNoListener    = errors.New("listener doesn't exist")
+	ErrSetWebhook    = errors.New("set webhook failed")
+	ErrDeleteWebhook = errors.New("delete webhook failed")
+	ErrWebhookURL    = errors.New("webhook url must be an absolute https url")
+	ErrWebhookSecret = errors.New("webhook secret must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	ErrQueueClosed   = errors.New("message queue closed")
+	// уведомления
+	ErrUnknownParseMode = errors.New("unknown parse mode")
+	ErrParseTemplate    = errors.New("parse notice template failed")
+	ErrExecuteTemplate  = errors.New("execute notice template failed")
 	// command
 	ErrStarComand = errors.New("'/start' failed")
 	// store
-	ErrExpiresToken  = errors.New("token expired")
-	ErrTokenNotExist = errors.New("token doesn't exist")
+	ErrExpiresToken   = errors.New("token expired")
+	ErrTokenNotExist  = errors.New("token doesn't exist")
+	ErrUnknownStorage = errors.New("unknown storage type")
+	ErrLoadStorage    = errors.New("load storage failed")
+	ErrSaveStorage    = errors.New("save storage failed")
+	ErrCursorNotExist = errors.New("cursor doesn't exist")
 	// авторизация
 	ErrDoTokenRequest    = errors.New("token request failed")
 	ErrInvalidStatusCode = errors.New("request with status not 200")
 	ErrHeaderContentType = errors.New("header Content-Type isn't application/json")
+	ErrUnauthorized      = errors.New("access token rejected")
+	ErrNoRefreshToken    = errors.New("refresh token doesn't exist")
+	ErrNoPendingAuth     = errors.New("no pending authorization")
+	ErrAuthInProgress    = errors.New("authorization already in progress")
+	ErrClaimSecret       = errors.New("generate claim secret failed")
+	ErrInvalidChat       = errors.New("chat must be chat_id or @username")
 	// запрос к серверу Яндекс
 	ErrServiceRequest = errors.New("request service failed")
 	ErrUnmarshalJSON  = errors.New("unmarshal JSON")
+	ErrDownloadFile   = errors.New("download file failed")
+	ErrFileTooLarge   = errors.New("file is larger than limit")
+	ErrPublishFile    = errors.New("publish file failed")
+	ErrUnpublishFile  = errors.New("unpublish file failed")
+	ErrUploadFile     = errors.New("upload file failed")
+	ErrFileExists     = errors.New("file already exists")
+	ErrBrowseDisk     = errors.New("browse disk failed")
+	ErrDiskInfo       = errors.New("request disk info failed")
+	ErrWalkFolder     = errors.New("walk folder failed")
+	// источники
+	ErrUnknownSource    = errors.New("unknown source type")
+	ErrNoFolders        = errors.New("no folders to watch")
+	ErrInvalidSourceURL = errors.New("invalid source url")
+	ErrUnmarshalXML     = errors.New("unmarshal XML")
+	ErrNoBucket         = errors.New("bucket isn't set")
+	ErrNoOAuthClient    = errors.New("client_id and client_secret are required for yandex source")
 )
@@ -5,44 +5,217 @@ import (
 	"fmt"
 	"strings"
 	"time"
+)
+
+// состояние access токена
+type TokenState int
 
-	"github.com/VoC925/tgBotNotice/internal/config"
+const (
+	TokenExpired      TokenState = iota // токен истек
+	TokenExpiringSoon                   // токен валиден, но скоро истечет
+	TokenValid                          // токен валиден
 )
 
 // структура access токена
 type Token struct {
-	Value   string `json:"access_token"` // токен
-	Expires int64  `json:"expires_in"`   // время жизни токена в секундах
+	Value        string    `json:"access_token"`  // токен
+	Expires      int64     `json:"expires_in"`    // время жизни токена в секундах, как его вернул Яндекс
+	RefreshToken string    `json:"refresh_token"` // токен для обновления access токена
+	IssuedAt     time.Time `json:"issued_at"`     // момент получения токена
+	ExpiresAt    time.Time `json:"expires_at"`    // момент, когда токен перестанет быть валидным
+}
+
+// метод устанавливает абсолютное время жизни токена относительно момента получения now
+func (t *Token) SetIssued(now time.Time) {
+	t.IssuedAt = now
+	t.ExpiresAt = now.Add(time.Duration(t.Expires) * time.Second)
+}
+
+// метод возвращает состояние токена на момент now
+// soon - за сколько до истечения токен считается "скоро истекающим"
+func (t Token) State(now time.Time, soon time.Duration) TokenState {
+	switch {
+	case !now.Before(t.ExpiresAt):
+		return TokenExpired
+	case now.Add(soon).After(t.ExpiresAt):
+		return TokenExpiringSoon
+	default:
+		return TokenValid
+	}
 }
 
-// если время Expires больше, чем текущее время, то токен валиден (true)
+// если момент ExpiresAt еще не наступил, то токен валиден (true)
 func (t Token) IsValid() bool {
-	return t.Expires > time.Now().Unix()
+	return time.Now().Before(t.ExpiresAt)
+}
+
+const (
+	DiskPrefix = "disk:" // префикс путей в ответах API Яндекс Диска
+)
+
+// функция убирает схему из пути: "disk:/папка" -> "/папка"
+func StripScheme(path string) string {
+	index := strings.Index(path, ":")
+	if index == -1 || strings.Contains(path[:index], "/") {
+		return path
+	}
+	return path[index+1:]
+}
+
+// функция приводит папку, введенную пользователем, к виду "/папка"
+// схема источника (например, "disk:") отбрасывается, чтобы папки одинаково работали для любого источника
+func NormalizeFolder(folder string) string {
+	folder = StripScheme(strings.TrimSpace(folder))
+	return "/" + strings.Trim(folder, "/")
+}
+
+// функция проверяет, лежит ли path внутри папки folder (в том числе во вложенных папках)
+// folder должна быть приведена функцией NormalizeFolder
+func InFolder(path, folder string) bool {
+	path = StripScheme(path)
+	return folder == "/" || path == folder || strings.HasPrefix(path, folder+"/")
+}
+
+// тип события с файлом
+type EventType int
+
+const (
+	EventCreated  EventType = iota // файл загружен
+	EventModified                  // содержимое файла изменилось
+	EventMoved                     // файл перемещен или переименован
+	EventDeleted                   // файл удален
+)
+
+// описание события для пользователя
+func (e EventType) String() string {
+	switch e {
+	case EventModified:
+		return "Файл изменен"
+	case EventMoved:
+		return "Файл перемещен"
+	case EventDeleted:
+		return "Файл удален"
+	default:
+		return "Новый файл"
+	}
+}
+
+// роль пользователя бота, роли упорядочены: каждая следующая может все, что и предыдущая
+type Role int
+
+const (
+	RoleGuest  Role = iota // пользователь без доступа, может только запросить доступ
+	RoleMember             // участник: уведомления, просмотр и загрузка файлов
+	RoleAdmin              // администратор: одобряет участников, публикует файлы
+	RoleOwner              // владелец бота, назначает администраторов
+)
+
+// название роли, оно же используется в командах
+func (r Role) String() string {
+	switch r {
+	case RoleMember:
+		return "member"
+	case RoleAdmin:
+		return "admin"
+	case RoleOwner:
+		return "owner"
+	default:
+		return "guest"
+	}
+}
+
+// функция возвращает роль по названию, false - такой роли нет
+func ParseRole(s string) (Role, bool) {
+	for _, r := range []Role{RoleGuest, RoleMember, RoleAdmin, RoleOwner} {
+		if strings.EqualFold(s, r.String()) {
+			return r, true
+		}
+	}
+	return RoleGuest, false
+}
+
+// пользователь с выданным доступом, ключ - user ID в Telegram
+type Member struct {
+	Role Role   `json:"role"`
+	Name string `json:"name"` // имя или никнейм на момент выдачи доступа, только для списка участников
+}
+
+// сведения о чате слушателя для списка слушателей
+type ChatInfo struct {
+	Title     string    `json:"title,omitempty"`     // название группы, @username или имя пользователя
+	Type      string    `json:"type,omitempty"`      // тип чата: private, group, supergroup или channel
+	Delivered time.Time `json:"delivered,omitempty"` // время последней доставки сообщения в чат
 }
 
 // структура нового обновления
 type UpdateInfo struct {
-	Title     string    `json:"name"`
-	Path      string    `json:"path"`
-	CreatedAt time.Time `json:"created"`
+	Title      string    `json:"name"`
+	Path       string    `json:"path"`
+	Type       string    `json:"type"` // file или dir
+	CreatedAt  time.Time `json:"created"`
+	ModifiedAt time.Time `json:"modified"`
+	ResourceID string    `json:"resource_id"`
+	MD5        string    `json:"md5"`
+	ETag       string    `json:"etag,omitempty"` // ETag ресурса для источников WebDAV и S3
+	Size       int64     `json:"size"`           // размер файла в байтах
+	MimeType   string    `json:"mime_type"`
+	MediaType  string    `json:"media_type"` // тип файла по мнению Яндекса: image, video, document и т.д.
+	Preview    string    `json:"preview"`    // ссылка на превью изображения
+	Event      EventType `json:"-"`          // событие, которое произошло с файлом
+	OldPath    string    `json:"-"`          // путь до перемещения, только для EventMoved
+}
+
+// время события: для загрузки - время создания, для остальных - время изменения
+func (ui UpdateInfo) EventTime() time.Time {
+	if ui.Event == EventCreated || ui.ModifiedAt.IsZero() {
+		return ui.CreatedAt
+	}
+	return ui.ModifiedAt
+}
+
+// метод проверяет, является ли файл изображением
+func (ui UpdateInfo) IsImage() bool {
+	return ui.MediaType == "image" || strings.HasPrefix(ui.MimeType, "image/")
+}
+
+// ключ, однозначно определяющий загруженный файл: путь + md5 содержимого
+// если md5 нет (например, у папки), то используется resource_id
+func (ui UpdateInfo) Key() string {
+	if ui.MD5 != "" {
+		return ui.Path + "|" + ui.MD5
+	}
+	return ui.Path + "|" + ui.ResourceID
 }
 
 type UpdateInfoSlice []*UpdateInfo
 
-func (ui UpdateInfoSlice) String() string {
-	var str strings.Builder
-	for index, elem := range ui {
-		str.WriteString(fmt.Sprintf("%d) %s",
-			index+1,
-			fmt.Sprintf(
-				config.UpdateResponseTemplate,
-				elem.Title,
-				elem.CreatedAt.Format(time.DateTime),
-				elem.Path,
-			)))
-		str.WriteString("\n")
+// объем Диска в байтах
+type DiskInfo struct {
+	TotalSpace int64 `json:"total_space"`
+	UsedSpace  int64 `json:"used_space"` // вместе с корзиной
+	TrashSize  int64 `json:"trash_size"`
+}
+
+// процент заполненности Диска
+func (d DiskInfo) UsedPercent() float64 {
+	if d.TotalSpace <= 0 {
+		return 0
 	}
-	return str.String()
+	return float64(d.UsedSpace) * 100 / float64(d.TotalSpace)
+}
+
+// свободное место на Диске
+func (d DiskInfo) FreeSpace() int64 {
+	return max(d.TotalSpace-d.UsedSpace, 0)
+}
+
+// сообщение, которое не удалось доставить в чат
+type DeadLetter struct {
+	ChatID   int64     `json:"chat_id"`
+	Text     string    `json:"text"`      // текст сообщения или его тип, если это не текст
+	Error    string    `json:"error"`     // последняя ошибка отправки
+	Attempts int       `json:"attempts"`  // количество попыток отправки
+	FailedAt time.Time `json:"failed_at"` // момент, когда попытки прекратились
 }
 
 // переопределение метода десереализации
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,3 +23,39 @@ func TestUnmarshalJSON(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEqual(t, len(sliceData), 0)
 }
+
+func TestTokenState(t *testing.T) {
+	now := time.Now()
+	soon := 24 * time.Hour
+
+	testCases := []struct {
+		name     string
+		expires  int64
+		issuedAt time.Time
+		want     TokenState
+	}{
+		{"fresh", int64((30 * 24 * time.Hour).Seconds()), now, TokenValid},
+		{"near expiry", int64((2 * time.Hour).Seconds()), now, TokenExpiringSoon},
+		{"expired", int64((2 * time.Hour).Seconds()), now.Add(-3 * time.Hour), TokenExpired},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := Token{Value: "token", Expires: tc.expires}
+			token.SetIssued(tc.issuedAt)
+			assert.Equal(t, tc.want, token.State(now, soon))
+			assert.Equal(t, tc.want != TokenExpired, token.IsValid())
+		})
+	}
+}
+
+func TestFolders(t *testing.T) {
+	assert.Equal(t, "/Общее", NormalizeFolder("/Общее/"))
+	assert.Equal(t, "/Общее/Фото", NormalizeFolder("disk:/Общее/Фото"))
+	assert.Equal(t, "/", NormalizeFolder("/"))
+	assert.Equal(t, "/mnt/nas", NormalizeFolder("/mnt/nas"))
+
+	assert.True(t, InFolder("disk:/Общее/a.txt", "/"))
+	assert.True(t, InFolder("disk:/Общее/Фото/a.jpg", "/Общее"))
+	assert.True(t, InFolder("/mnt/nas/a.txt", "/mnt/nas"))
+	assert.False(t, InFolder("disk:/Общее2/a.txt", "/Общее"))
+}
@@ -0,0 +1,278 @@
+package notice
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/VoC925/tgBotNotice/internal/config"
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// максимальная длина сообщения Telegram в символах
+const maxMessageLength = 4096
+
+// длины, до которых сокращаются имя и пути файла, если уведомление не помещается в сообщение
+var shortFieldLengths = []int{256, 32}
+
+// символы, которые необходимо экранировать в MarkdownV2
+var markdownV2Replacer = strings.NewReplacer(
+	`\`, `\\`, `_`, `\_`, `*`, `\*`, `[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`,
+	`~`, `\~`, "`", "\\`", `>`, `\>`, `#`, `\#`, `+`, `\+`, `-`, `\-`, `=`, `\=`,
+	`|`, `\|`, `{`, `\{`, `}`, `\}`, `.`, `\.`, `!`, `\!`,
+)
+
+// в адресе ссылки MarkdownV2 экранируются только ) и \
+var markdownV2LinkReplacer = strings.NewReplacer(`\`, `\\`, `)`, `\)`)
+
+// данные одного уведомления для шаблона, все строки уже экранированы под parse_mode
+type Item struct {
+	Index    int    // номер файла в уведомлении
+	Event    string // событие: загрузка, изменение, перемещение, удаление
+	Title    string // имя файла
+	Date     string // время события
+	Path     string // путь до файла
+	OldPath  string // путь до перемещения
+	Folder   string // папка с файлом
+	Hashtag  string // папка в виде хэштега, пустой - файл в корне
+	Size     string // размер в читаемом виде, пустой - размер неизвестен
+	MimeType string // MIME тип файла
+	Link     string // ссылка для открытия файла, пустая - у источника нет веб-интерфейса
+}
+
+// сообщение с уведомлениями о нескольких файлах
+type Message struct {
+	Text   string                 // текст сообщения
+	Offset int                    // номер первого файла сообщения минус один
+	Items  models.UpdateInfoSlice // файлы, которые вошли в сообщение
+}
+
+// структура для формирования текста уведомлений
+type Renderer struct {
+	parseMode string
+	tmpl      *template.Template
+}
+
+// конструктор
+// parseMode - режим разметки Telegram: HTML, MarkdownV2 или пустой для обычного текста
+// text - шаблон одного файла, пустой - шаблон по умолчанию для parseMode
+func NewRenderer(parseMode, text string) (*Renderer, error) {
+	var defaultText string
+	switch parseMode {
+	case "":
+		defaultText = config.NoticeTemplatePlain
+	case tgbotapi.ModeHTML:
+		defaultText = config.NoticeTemplateHTML
+	case tgbotapi.ModeMarkdownV2:
+		defaultText = config.NoticeTemplateMarkdownV2
+	default:
+		return nil, fmt.Errorf("%w: %s", errorApi.ErrUnknownParseMode, parseMode)
+	}
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New("notice").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrParseTemplate, err)
+	}
+	return &Renderer{
+		parseMode: parseMode,
+		tmpl:      tmpl,
+	}, nil
+}
+
+// режим разметки, с которым нужно отправлять сообщения
+func (r *Renderer) ParseMode() string {
+	return r.parseMode
+}
+
+// метод формирует сообщения с уведомлениями о файлах
+// файлы разбиваются на несколько сообщений, если не помещаются в одно
+func (r *Renderer) Render(data models.UpdateInfoSlice) ([]Message, error) {
+	var (
+		msgs    []Message
+		current strings.Builder
+		start   int // индекс первого файла текущего сообщения
+	)
+	for index, elem := range data {
+		text, err := r.fitItem(index+1, elem)
+		if err != nil {
+			return nil, err
+		}
+		// текст уведомлений разделяется пустой строкой
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(text)+2 > maxMessageLength {
+			msgs = append(msgs, Message{Text: current.String(), Offset: start, Items: data[start:index]})
+			current.Reset()
+			start = index
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(text)
+	}
+	if current.Len() > 0 {
+		msgs = append(msgs, Message{Text: current.String(), Offset: start, Items: data[start:]})
+	}
+	return msgs, nil
+}
+
+// метод формирует текст уведомления об одном файле, например для подписи к фото
+func (r *Renderer) RenderItem(index int, ui *models.UpdateInfo) (string, error) {
+	var text strings.Builder
+	if err := r.tmpl.Execute(&text, r.item(index, ui)); err != nil {
+		return "", fmt.Errorf("%w: %w", errorApi.ErrExecuteTemplate, err)
+	}
+	return text.String(), nil
+}
+
+// метод формирует текст уведомления об одном файле, который помещается в одно сообщение
+// слишком длинные имя и пути файла сокращаются до экранирования, чтобы не сломать разметку,
+// если шаблон все равно не помещается, то текст обрезается
+func (r *Renderer) fitItem(index int, ui *models.UpdateInfo) (string, error) {
+	text, err := r.RenderItem(index, ui)
+	if err != nil || utf8.RuneCountInString(text) <= maxMessageLength {
+		return text, err
+	}
+	for _, limit := range shortFieldLengths {
+		short := *ui
+		short.Title = shorten(ui.Title, limit)
+		short.Path = shorten(ui.Path, limit)
+		short.OldPath = shorten(ui.OldPath, limit)
+		item := r.item(index, &short)
+		// ссылка строится из полного пути, сокращенный путь дал бы неверную ссылку
+		item.Link = ""
+		var b strings.Builder
+		if err := r.tmpl.Execute(&b, item); err != nil {
+			return "", fmt.Errorf("%w: %w", errorApi.ErrExecuteTemplate, err)
+		}
+		if text = b.String(); utf8.RuneCountInString(text) <= maxMessageLength {
+			return text, nil
+		}
+	}
+	return shorten(text, maxMessageLength), nil
+}
+
+// функция сокращает строку до limit символов, обрезанная строка заканчивается многоточием
+func shorten(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit-1]) + "…"
+}
+
+// метод подготавливает данные файла для шаблона
+func (r *Renderer) item(index int, ui *models.UpdateInfo) Item {
+	folder := path.Dir(models.StripScheme(ui.Path))
+	item := Item{
+		Index:    index,
+		Event:    r.escape(ui.Event.String()),
+		Title:    r.escape(ui.Title),
+		Date:     r.escape(ui.EventTime().Format(time.DateTime)),
+		Path:     r.escape(ui.Path),
+		Folder:   r.escape(folder),
+		Hashtag:  r.escape(Hashtag(folder)),
+		Size:     r.escape(HumanSize(ui.Size)),
+		MimeType: r.escape(ui.MimeType),
+		Link:     r.escapeLink(FileURL(ui)),
+	}
+	if ui.Event == models.EventMoved {
+		item.OldPath = r.escape(ui.OldPath)
+	}
+	return item
+}
+
+// метод экранирует текст под режим разметки
+func (r *Renderer) escape(s string) string {
+	switch r.parseMode {
+	case tgbotapi.ModeHTML:
+		return html.EscapeString(s)
+	case tgbotapi.ModeMarkdownV2:
+		return markdownV2Replacer.Replace(s)
+	default:
+		return s
+	}
+}
+
+// метод экранирует адрес ссылки под режим разметки
+func (r *Renderer) escapeLink(s string) string {
+	switch r.parseMode {
+	case tgbotapi.ModeHTML:
+		return html.EscapeString(s)
+	case tgbotapi.ModeMarkdownV2:
+		return markdownV2LinkReplacer.Replace(s)
+	default:
+		return s
+	}
+}
+
+// функция возвращает ссылку для открытия файла в веб-интерфейсе Яндекс Диска
+// для файлов других источников ссылки нет
+func FileURL(ui *models.UpdateInfo) string {
+	if !strings.HasPrefix(ui.Path, models.DiskPrefix) || ui.Event == models.EventDeleted {
+		return ""
+	}
+	filePath := models.StripScheme(ui.Path)
+	if ui.Type == "dir" {
+		return config.DiskWebURL + (&url.URL{Path: filePath}).EscapedPath()
+	}
+	// файл открывается в просмотрщике поверх своей папки
+	return fmt.Sprintf("%s%s?idApp=client&dialog=slider&idDialog=%s",
+		config.DiskWebURL,
+		(&url.URL{Path: path.Dir(filePath)}).EscapedPath(),
+		url.QueryEscape("/disk"+filePath),
+	)
+}
+
+// функция превращает папку в хэштег: /Общее/Отчеты 2024 -> #Общее_Отчеты_2024
+// в хэштеге допустимы только буквы, цифры и _
+func Hashtag(folder string) string {
+	folder = strings.Trim(folder, "/.")
+	if folder == "" {
+		return ""
+	}
+	var b strings.Builder
+	underscore := false
+	for _, r := range folder {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		// несколько разделителей подряд превращаются в один _
+		if !underscore && b.Len() > 0 {
+			b.WriteRune('_')
+			underscore = true
+		}
+	}
+	tag := strings.TrimSuffix(b.String(), "_")
+	if tag == "" {
+		return ""
+	}
+	return "#" + tag
+}
+
+// функция возвращает размер в читаемом виде: 1536 -> 1.5 КБ
+func HumanSize(size int64) string {
+	if size <= 0 {
+		return ""
+	}
+	units := []string{"Б", "КБ", "МБ", "ГБ", "ТБ"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,110 @@
+package notice
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFile() *models.UpdateInfo {
+	return &models.UpdateInfo{
+		Title:     "a<b>_1.txt",
+		Path:      "disk:/Общее/Отчеты 2024/a<b>_1.txt",
+		Type:      "file",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Size:      1536,
+		MimeType:  "text/plain",
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	r, err := NewRenderer("HTML", "")
+	require.NoError(t, err)
+	msgs, err := r.Render(models.UpdateInfoSlice{testFile()})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Contains(t, msgs[0].Text, "a&lt;b&gt;_1.txt")
+	assert.Contains(t, msgs[0].Text, "#Общее_Отчеты_2024")
+	assert.Contains(t, msgs[0].Text, "1.5 КБ")
+	assert.Contains(t, msgs[0].Text, `<a href="https://disk.yandex.ru/client/disk/%D0%9E%D0%B1%D1%89%D0%B5%D0%B5/`)
+	assert.NotContains(t, msgs[0].Text, "<b>_1")
+}
+
+func TestRenderMarkdownV2(t *testing.T) {
+	r, err := NewRenderer("MarkdownV2", "")
+	require.NoError(t, err)
+	msgs, err := r.Render(models.UpdateInfoSlice{testFile()})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Contains(t, msgs[0].Text, `[a<b\>\_1\.txt](https://disk.yandex.ru/client/disk/`)
+	assert.Contains(t, msgs[0].Text, `\#Общее\_Отчеты\_2024`)
+	assert.Contains(t, msgs[0].Text, `2024\-05\-01 12:00:00`)
+}
+
+func TestRenderCustomTemplate(t *testing.T) {
+	r, err := NewRenderer("", "{{.Index}}: {{.Title}} {{.Size}}")
+	require.NoError(t, err)
+	file := testFile()
+	file.Path = "/srv/files/a.txt" // у локальных файлов ссылки нет
+	msgs, err := r.Render(models.UpdateInfoSlice{file, file})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "1: a<b>_1.txt 1.5 КБ\n\n2: a<b>_1.txt 1.5 КБ", msgs[0].Text)
+}
+
+func TestRenderSplit(t *testing.T) {
+	r, err := NewRenderer("", "{{.Title}}")
+	require.NoError(t, err)
+	file := testFile()
+	file.Title = strings.Repeat("я", 3000)
+	msgs, err := r.Render(models.UpdateInfoSlice{file, file, file})
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, 2, msgs[2].Offset)
+	assert.Len(t, msgs[2].Items, 1)
+}
+
+func TestRenderLongItem(t *testing.T) {
+	r, err := NewRenderer("HTML", "")
+	require.NoError(t, err)
+	file := testFile()
+	file.Title = strings.Repeat("<я>", 2000)
+	file.Path = "disk:/Общее/" + file.Title
+	msgs, err := r.Render(models.UpdateInfoSlice{file, testFile()})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.LessOrEqual(t, utf8.RuneCountInString(msgs[0].Text), maxMessageLength)
+	// имя сокращается до экранирования, разметка остается целой
+	assert.Contains(t, msgs[0].Text, "<b>"+strings.Repeat("&lt;я&gt;", 85)+"…</b>")
+	assert.Contains(t, msgs[0].Text, "a&lt;b&gt;_1.txt")
+
+	// шаблон длиннее сообщения обрезается
+	r, err = NewRenderer("", strings.Repeat("я", maxMessageLength)+"{{.Title}}")
+	require.NoError(t, err)
+	msgs, err = r.Render(models.UpdateInfoSlice{testFile()})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, maxMessageLength, utf8.RuneCountInString(msgs[0].Text))
+	assert.True(t, strings.HasSuffix(msgs[0].Text, "…"))
+}
+
+func TestNewRendererErrors(t *testing.T) {
+	_, err := NewRenderer("Markdown", "")
+	assert.ErrorIs(t, err, errorApi.ErrUnknownParseMode)
+	_, err = NewRenderer("HTML", "{{.Title")
+	assert.ErrorIs(t, err, errorApi.ErrParseTemplate)
+}
+
+func TestHelpers(t *testing.T) {
+	assert.Equal(t, "", HumanSize(0))
+	assert.Equal(t, "512 Б", HumanSize(512))
+	assert.Equal(t, "2.0 МБ", HumanSize(2*1024*1024))
+	assert.Equal(t, "", Hashtag("/"))
+	assert.Equal(t, "#Фото_2024_лето", Hashtag("/Фото/2024 - лето"))
+}
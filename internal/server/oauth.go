@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second // время на корректное завершение сервера
+)
+
+// ответы сервера пользователю в браузере
+const (
+	respSuccess      = "Авторизация прошла успешно, можно вернуться в Telegram"
+	respDenied       = "Доступ не был предоставлен: %s"
+	respNoCode       = "В запросе отсутствует код подтверждения"
+	respInvalidState = "Ссылка авторизации недействительна, запросите новую командой /auth"
+	respTokenFail    = "Не удалось получить токен, попробуйте снова"
+	respNoWaiter     = "Время авторизации истекло, запросите новую ссылку командой /auth"
+)
+
+// интерфейс для обмена кода подтверждения на токен
+type TokenRequester interface {
+	RequestToken(code string) (*models.Token, error)
+}
+
+// интерфейс получателя токена, то есть запущенного бота
+type TokenReceiver interface {
+	ConsumeState(state string) bool     // проверяет одноразовый nonce из параметра state
+	ReceiveToken(t *models.Token) error // передает полученный токен
+}
+
+// HTTP сервер, принимающий redirect_uri от OAuth Яндекса
+type OAuthServer struct {
+	srv       *http.Server
+	requester TokenRequester
+	receiver  TokenReceiver
+}
+
+// конструктор сервера
+// host, port - адрес, на котором слушает сервер
+// path - путь колбэка, который указан в redirect_uri
+func NewOAuthServer(host string, port int, path string, requester TokenRequester, receiver TokenReceiver) *OAuthServer {
+	s := &OAuthServer{
+		requester: requester,
+		receiver:  receiver,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, s.handleCallback)
+	s.srv = &http.Server{
+		Addr:              net.JoinHostPort(host, strconv.Itoa(port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// метод запускает сервер, блокирующий
+func (s *OAuthServer) Start() error {
+	slog.With(slog.String("addr", s.srv.Addr)).Info("oauth callback server started")
+	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// метод останавливает сервер
+func (s *OAuthServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	slog.Info("oauth callback server stopped")
+	return s.srv.Shutdown(ctx)
+}
+
+// обработчик колбэка вида /callback?code=<код>&state=<nonce>
+func (s *OAuthServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	// пользователь отказал в доступе
+	if errParam := query.Get("error"); errParam != "" {
+		slog.With(slog.String("error", errParam)).Info("oauth access denied")
+		writeText(w, http.StatusForbidden, fmt.Sprintf(respDenied, errParam))
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		writeText(w, http.StatusBadRequest, respNoCode)
+		return
+	}
+	// state должен совпадать с nonce, который бот положил в ссылку авторизации
+	if !s.receiver.ConsumeState(query.Get("state")) {
+		slog.Warn("oauth callback with invalid state")
+		writeText(w, http.StatusBadRequest, respInvalidState)
+		return
+	}
+	t, err := s.requester.RequestToken(code)
+	if err != nil {
+		slog.With(slog.Any("error", err)).Error("oauth callback token request failed")
+		writeText(w, http.StatusBadGateway, respTokenFail)
+		return
+	}
+	if err := s.receiver.ReceiveToken(t); err != nil {
+		slog.With(slog.Any("error", err)).Error("oauth callback token not delivered")
+		writeText(w, http.StatusGone, respNoWaiter)
+		return
+	}
+	writeText(w, http.StatusOK, respSuccess)
+}
+
+// функция записывает текстовый ответ
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, text)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRequester struct {
+	err error
+}
+
+func (f *fakeRequester) RequestToken(code string) (*models.Token, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.Token{Value: "token_" + code}, nil
+}
+
+type fakeReceiver struct {
+	state string
+	token *models.Token
+}
+
+func (f *fakeReceiver) ConsumeState(state string) bool {
+	if state == "" || state != f.state {
+		return false
+	}
+	f.state = ""
+	return true
+}
+
+func (f *fakeReceiver) ReceiveToken(t *models.Token) error {
+	f.token = t
+	return nil
+}
+
+func TestHandleCallback(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		reqErr    error
+		wantCode  int
+		wantToken string
+	}{
+		{"success", "?code=123&state=nonce", nil, http.StatusOK, "token_123"},
+		{"invalid state", "?code=123&state=other", nil, http.StatusBadRequest, ""},
+		{"no code", "?state=nonce", nil, http.StatusBadRequest, ""},
+		{"access denied", "?error=access_denied&state=nonce", nil, http.StatusForbidden, ""},
+		{"token request failed", "?code=123&state=nonce", errors.New("fail"), http.StatusBadGateway, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			receiver := &fakeReceiver{state: "nonce"}
+			s := NewOAuthServer("localhost", 0, "/callback", &fakeRequester{err: tc.reqErr}, receiver)
+
+			rec := httptest.NewRecorder()
+			s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback"+tc.query, nil))
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantToken == "" {
+				assert.Nil(t, receiver.token)
+				return
+			}
+			assert.Equal(t, tc.wantToken, receiver.token.Value)
+		})
+	}
+}
+
+func TestHandleCallbackStateIsOneTime(t *testing.T) {
+	receiver := &fakeReceiver{state: "nonce"}
+	s := NewOAuthServer("localhost", 0, "/callback", &fakeRequester{}, receiver)
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback?code=1&state=nonce", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// повторный запрос с тем же nonce отклоняется
+	rec = httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback?code=2&state=nonce", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package local
+
+import "io/fs"
+
+// без inode файлы нельзя надежно сопоставить, поэтому перемещения не определяются:
+// перемещение приходит удалением и созданием файла
+func resourceID(info fs.FileInfo) string {
+	return ""
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package local
+
+import (
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+// функция возвращает идентификатор файла: номер устройства и inode
+// inode не меняется при переименовании внутри устройства, поэтому по нему определяется перемещение
+func resourceID(info fs.FileInfo) string {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uint64(st.Dev), uint64(st.Ino))
+}
@@ -0,0 +1,78 @@
+package local
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"path/filepath"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/source"
+)
+
+// источник событий из локальной файловой системы (в том числе смонтированных NAS папок)
+// изменения определяются периодическим обходом папок и сравнением снимков
+type localSource struct {
+	*source.Poller
+	folders []string // отслеживаемые папки, абсолютные пути
+}
+
+// конструктор источника
+// folders - отслеживаемые папки, pause - период обхода
+func NewLocalSource(folders []string, pause time.Duration) (source.Source, error) {
+	if len(folders) == 0 {
+		return nil, errorApi.ErrNoFolders
+	}
+	s := &localSource{}
+	for _, folder := range folders {
+		abs, err := filepath.Abs(folder)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", errorApi.ErrWalkFolder, folder, err)
+		}
+		s.folders = append(s.folders, abs)
+	}
+	s.Poller = source.NewPoller(source.TypeLocal, pause, s.scan)
+	return s, nil
+}
+
+// метод делает снимок всех отслеживаемых папок
+func (s *localSource) scan() (source.Snapshot, error) {
+	snapshot := make(source.Snapshot)
+	for _, folder := range s.folders {
+		if err := scanFolder(folder, snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// функция рекурсивно обходит папку и складывает файлы в snapshot
+func scanFolder(folder string, snapshot source.Snapshot) error {
+	return filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("%w: %w", errorApi.ErrWalkFolder, err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("%w: %w", errorApi.ErrWalkFolder, err)
+		}
+		snapshot[path] = &models.UpdateInfo{
+			Title:      d.Name(),
+			Path:       path,
+			Type:       "file",
+			CreatedAt:  info.ModTime(),
+			ModifiedAt: info.ModTime(),
+			// хэш содержимого не считается, чтобы не читать все файлы на каждом обходе,
+			// поэтому перемещение определяется по устройству и inode, если они доступны
+			ResourceID: resourceID(info),
+			Size:       info.Size(),
+			MimeType:   mime.TypeByExtension(filepath.Ext(path)),
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSourcePoll(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+
+	src, err := NewLocalSource([]string{dir}, time.Second)
+	require.NoError(t, err)
+	s := src.(*localSource)
+
+	// первый обход только запоминает файлы
+	assert.Empty(t, s.Poll())
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0644))
+	require.NoError(t, os.Rename(filepath.Join(dir, "a.txt"), filepath.Join(dir, "sub", "a.txt")))
+
+	events := s.Poll()
+	require.Len(t, events, 2)
+	assert.Equal(t, models.EventMoved, events[0].Event)
+	assert.Equal(t, filepath.Join(dir, "sub", "a.txt"), events[0].Path)
+	assert.Equal(t, filepath.Join(dir, "a.txt"), events[0].OldPath)
+	assert.Equal(t, models.EventCreated, events[1].Event)
+	assert.Equal(t, "b.txt", events[1].Title)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "sub", "b.txt")))
+	events = s.Poll()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventDeleted, events[0].Event)
+}
+
+func TestNewLocalSourceNoFolders(t *testing.T) {
+	_, err := NewLocalSource(nil, time.Second)
+	require.Error(t, err)
+}
+
+func TestLocalSourceCopyIsNotMove(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	a := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(a, []byte("a"), 0644))
+	require.NoError(t, os.Chtimes(a, mtime, mtime))
+
+	src, err := NewLocalSource([]string{dir}, time.Second)
+	require.NoError(t, err)
+	s := src.(*localSource)
+	assert.Empty(t, s.Poll())
+
+	// другой файл с тем же размером и временем изменения появился, а первый удален
+	b := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(b, []byte("b"), 0644))
+	require.NoError(t, os.Chtimes(b, mtime, mtime))
+	require.NoError(t, os.Remove(a))
+
+	events := s.Poll()
+	require.Len(t, events, 2)
+	assert.Equal(t, models.EventDeleted, events[0].Event)
+	assert.Equal(t, models.EventCreated, events[1].Event)
+}
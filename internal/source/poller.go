@@ -0,0 +1,85 @@
+package source
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// функция, которая делает снимок файлов источника
+type ScanFunc func() (Snapshot, error)
+
+// общий цикл опроса для источников, построенных на сравнении снимков
+// реализует интерфейс Source: источнику достаточно уметь делать снимок
+type Poller struct {
+	name         string        // название источника для логов
+	pauseRequest time.Duration // период опроса
+	scan         ScanFunc
+	differ       Differ
+	eventsCh     chan *models.UpdateInfoSlice // канал для отправки событий
+	stopCh       chan struct{}                // канал для остановки опроса
+}
+
+// конструктор цикла опроса
+// name - название источника, pause - период опроса, scan - функция снимка
+func NewPoller(name string, pause time.Duration, scan ScanFunc) *Poller {
+	return &Poller{
+		name:         name,
+		pauseRequest: pause,
+		scan:         scan,
+		eventsCh:     make(chan *models.UpdateInfoSlice),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+func (p *Poller) Events() <-chan *models.UpdateInfoSlice {
+	return p.eventsCh
+}
+
+// метод опрашивает источник с периодом pauseRequest, пока не будет вызван Stop
+func (p *Poller) Watch() {
+	// первый снимок создается сразу, чтобы не пропустить изменения до первого тика
+	p.Poll()
+
+	ticker := time.NewTicker(p.pauseRequest)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			events := p.Poll()
+			if len(events) == 0 {
+				continue
+			}
+			select {
+			case p.eventsCh <- &events:
+			case <-p.stopCh:
+				return
+			}
+		case <-p.stopCh:
+			slog.With(slog.String("source", p.name)).Debug("опрос источника остановлен")
+			return
+		}
+	}
+}
+
+func (p *Poller) Stop() {
+	close(p.stopCh)
+	p.stopCh = make(chan struct{})
+}
+
+func (p *Poller) Close() error {
+	close(p.eventsCh)
+	return nil
+}
+
+// метод делает снимок и возвращает события относительно предыдущего снимка
+func (p *Poller) Poll() models.UpdateInfoSlice {
+	snapshot, err := p.scan()
+	if err != nil {
+		// при неполном снимке нельзя сравнивать снимки, иначе появятся ложные удаления
+		slog.With(slog.Any("error", err), slog.String("source", p.name)).Error("scan source failed")
+		return nil
+	}
+	return p.differ.Diff(snapshot)
+}
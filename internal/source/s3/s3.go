@@ -0,0 +1,158 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/source"
+)
+
+// ответ ListObjectsV2
+type listBucketResult struct {
+	Contents              []object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+type object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int64     `xml:"Size"`
+}
+
+// параметры подключения к S3 совместимому хранилищу
+type Options struct {
+	Endpoint  string   // адрес хранилища, например http://localhost:9000 для MinIO
+	Region    string   // регион для подписи запросов
+	Bucket    string   // бакет
+	AccessKey string   // ключ доступа
+	SecretKey string   // секретный ключ
+	Prefixes  []string // отслеживаемые префиксы ключей, пусто - весь бакет
+	PathStyle bool     // true - адрес вида endpoint/bucket (MinIO), false - bucket.endpoint (AWS)
+}
+
+// источник событий из S3 совместимого бакета (MinIO, AWS S3 и т.д.)
+// объекты читаются запросами ListObjectsV2, изменения определяются по ETag и LastModified между опросами
+type s3Source struct {
+	*source.Poller
+	opts     Options
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// конструктор источника
+// pause - период опроса, timeout - таймаут запросов
+func NewS3Source(opts Options, pause, timeout time.Duration) (source.Source, error) {
+	if opts.Bucket == "" {
+		return nil, errorApi.ErrNoBucket
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: %s", errorApi.ErrInvalidSourceURL, opts.Endpoint)
+	}
+	if len(opts.Prefixes) == 0 {
+		opts.Prefixes = []string{""}
+	}
+	s := &s3Source{
+		opts:     opts,
+		endpoint: endpoint,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+	s.Poller = source.NewPoller(source.TypeS3, pause, s.scan)
+	return s, nil
+}
+
+// метод делает снимок всех отслеживаемых префиксов
+func (s *s3Source) scan() (source.Snapshot, error) {
+	snapshot := make(source.Snapshot)
+	for _, prefix := range s.opts.Prefixes {
+		if err := s.scanPrefix(prefix, snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// метод постранично читает объекты с префиксом и складывает их в snapshot
+func (s *s3Source) scanPrefix(prefix string, snapshot source.Snapshot) error {
+	token := ""
+	for {
+		result, err := s.listObjects(prefix, token)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errorApi.ErrWalkFolder, prefix, err)
+		}
+		for _, obj := range result.Contents {
+			// "папки", созданные через консоль, - пустые объекты с "/" на конце
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			key := "/" + obj.Key
+			snapshot[key] = &models.UpdateInfo{
+				Title:      path.Base(obj.Key),
+				Path:       key,
+				Type:       "file",
+				CreatedAt:  obj.LastModified,
+				ModifiedAt: obj.LastModified,
+				ETag:       strings.Trim(obj.ETag, `"`),
+				Size:       obj.Size,
+				MimeType:   mime.TypeByExtension(path.Ext(obj.Key)),
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// метод выполняет запрос ListObjectsV2
+func (s *s3Source) listObjects(prefix, token string) (*listBucketResult, error) {
+	u := *s.endpoint
+	if s.opts.PathStyle {
+		u.Path = "/" + s.opts.Bucket + "/"
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+		u.Path = "/"
+	}
+	query := url.Values{}
+	query.Set("list-type", "2")
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	signRequest(req, s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		slog.With(slog.Int("code", resp.StatusCode)).Debug("bad status code response")
+		return nil, errorApi.ErrInvalidStatusCode
+	}
+	result := &listBucketResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrUnmarshalXML, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// фейковый бакет, отвечающий на ListObjectsV2 страницами по одному объекту
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func (b *fakeBucket) put(key, etag string) {
+	b.mu.Lock()
+	b.objects[key] = object{Key: key, ETag: `"` + etag + `"`, LastModified: time.Now().UTC().Truncate(time.Second)}
+	b.mu.Unlock()
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signAlgorithm) || r.URL.Path != "/bucket/" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) && key > r.URL.Query().Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	result := listBucketResult{}
+	if len(keys) > 0 {
+		result.Contents = []object{b.objects[keys[0]]}
+	}
+	if len(keys) > 1 {
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[0]
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+func TestS3SourcePoll(t *testing.T) {
+	bucket := &fakeBucket{objects: make(map[string]object)}
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+
+	bucket.put("uploads/a.txt", "1")
+	bucket.put("other/b.txt", "2")
+
+	src, err := NewS3Source(Options{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "bucket",
+		AccessKey: "key",
+		SecretKey: "secret",
+		Prefixes:  []string{"uploads/"},
+		PathStyle: true,
+	}, time.Second, time.Second)
+	require.NoError(t, err)
+	s := src.(*s3Source)
+
+	// первый опрос только запоминает объекты
+	assert.Empty(t, s.Poll())
+
+	bucket.put("uploads/c.txt", "3")
+	bucket.put("uploads/d.txt", "4")
+	bucket.put("other/e.txt", "5")
+	events := s.Poll()
+	require.Len(t, events, 2)
+	assert.Equal(t, models.EventCreated, events[0].Event)
+	assert.Equal(t, "/uploads/c.txt", events[0].Path)
+	assert.Equal(t, "/uploads/d.txt", events[1].Path)
+
+	// перезапись объекта меняет ETag
+	bucket.put("uploads/a.txt", "6")
+	events = s.Poll()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventModified, events[0].Event)
+	assert.Equal(t, "6", events[0].ETag)
+}
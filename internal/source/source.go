@@ -0,0 +1,103 @@
+package source
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// типы источников
+const (
+	TypeYandex = "yandex" // Яндекс Диск через REST API
+	TypeLocal  = "local"  // локальная файловая система или смонтированная сетевая папка
+	TypeWebDAV = "webdav" // WebDAV хранилище (Nextcloud, webdav.yandex.ru)
+	TypeS3     = "s3"     // S3 совместимый бакет (MinIO, AWS S3)
+)
+
+// интерфейс источника событий с файлами
+type Source interface {
+	Events() <-chan *models.UpdateInfoSlice // канал, в который отправляются события
+	Watch()                                 // блокирующий опрос источника, работает до вызова Stop
+	Stop()                                  // остановка опроса
+	Close() error                           // закрытие источника
+}
+
+// снимок файлов источника, ключ - путь файла
+type Snapshot map[string]*models.UpdateInfo
+
+// сравнение снимков источника
+// по разнице предыдущего и текущего снимка определяются созданные, измененные, перемещенные и удаленные файлы
+type Differ struct {
+	prev Snapshot // предыдущий снимок, nil - снимков еще не было
+}
+
+// метод сравнивает снимок с предыдущим и запоминает его
+// первый снимок только запоминается
+func (d *Differ) Diff(current Snapshot) models.UpdateInfoSlice {
+	prev := d.prev
+	d.prev = current
+	if prev == nil {
+		slog.With(slog.Int("files", len(current))).Info("первый снимок источника создан")
+		return nil
+	}
+
+	var (
+		events  models.UpdateInfoSlice
+		created []*models.UpdateInfo
+		deleted []*models.UpdateInfo
+	)
+	for path, file := range current {
+		old, ok := prev[path]
+		switch {
+		case !ok:
+			created = append(created, file)
+		case old.MD5 != file.MD5 || old.ETag != file.ETag || !old.ModifiedAt.Equal(file.ModifiedAt):
+			events = append(events, withEvent(file, models.EventModified, ""))
+		}
+	}
+	for path, file := range prev {
+		if _, ok := current[path]; !ok {
+			deleted = append(deleted, file)
+		}
+	}
+	// файл, который пропал по одному пути и появился по другому с тем же содержимым, перемещен
+	for _, file := range created {
+		index := slices.IndexFunc(deleted, func(old *models.UpdateInfo) bool {
+			return sameResource(old, file)
+		})
+		if index == -1 {
+			events = append(events, withEvent(file, models.EventCreated, ""))
+			continue
+		}
+		events = append(events, withEvent(file, models.EventMoved, deleted[index].Path))
+		deleted = slices.Delete(deleted, index, index+1)
+	}
+	for _, file := range deleted {
+		events = append(events, withEvent(file, models.EventDeleted, ""))
+	}
+	slices.SortFunc(events, func(a, b *models.UpdateInfo) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return events
+}
+
+// функция проверяет, что два файла - один и тот же ресурс
+func sameResource(a, b *models.UpdateInfo) bool {
+	if a.ResourceID != "" && a.ResourceID == b.ResourceID {
+		return true
+	}
+	if a.ETag != "" && a.ETag == b.ETag {
+		return true
+	}
+	return a.MD5 != "" && a.MD5 == b.MD5
+}
+
+// функция возвращает копию файла с заданным событием
+func withEvent(file *models.UpdateInfo, event models.EventType, oldPath string) *models.UpdateInfo {
+	copyFile := *file
+	copyFile.Event = event
+	copyFile.OldPath = oldPath
+	return &copyFile
+}
@@ -0,0 +1,49 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshot(files ...*models.UpdateInfo) Snapshot {
+	s := make(Snapshot, len(files))
+	for _, f := range files {
+		s[f.Path] = f
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	d := Differ{}
+
+	a := &models.UpdateInfo{Path: "disk:/Общее/a.txt", MD5: "1", ModifiedAt: now}
+	b := &models.UpdateInfo{Path: "disk:/Общее/b.txt", MD5: "2", ModifiedAt: now}
+	c := &models.UpdateInfo{Path: "disk:/Общее/c.txt", MD5: "3", ModifiedAt: now}
+
+	// первый снимок только запоминается
+	assert.Empty(t, d.Diff(snapshot(a, b, c)))
+
+	aModified := &models.UpdateInfo{Path: a.Path, MD5: "4", ModifiedAt: now.Add(time.Minute)}
+	bMoved := &models.UpdateInfo{Path: "disk:/Общее/new/b.txt", MD5: "2", ModifiedAt: now}
+	e := &models.UpdateInfo{Path: "disk:/Общее/e.txt", MD5: "5", ModifiedAt: now}
+
+	events := d.Diff(snapshot(aModified, bMoved, e))
+	require.Len(t, events, 4)
+	assert.Equal(t, models.EventModified, events[0].Event)
+	assert.Equal(t, a.Path, events[0].Path)
+	assert.Equal(t, models.EventDeleted, events[1].Event)
+	assert.Equal(t, c.Path, events[1].Path)
+	assert.Equal(t, models.EventCreated, events[2].Event)
+	assert.Equal(t, e.Path, events[2].Path)
+	assert.Equal(t, models.EventMoved, events[3].Event)
+	assert.Equal(t, bMoved.Path, events[3].Path)
+	assert.Equal(t, b.Path, events[3].OldPath)
+
+	// без изменений событий нет
+	assert.Empty(t, d.Diff(snapshot(aModified, bMoved, e)))
+}
@@ -0,0 +1,198 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/VoC925/tgBotNotice/internal/source"
+)
+
+// тело запроса PROPFIND со свойствами, которые нужны для снимка
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:resourcetype/>
+    <D:getlastmodified/>
+    <D:getetag/>
+    <D:creationdate/>
+    <D:getcontentlength/>
+    <D:getcontenttype/>
+  </D:prop>
+</D:propfind>`
+
+// ответ PROPFIND (207 Multi-Status)
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	ResourceType struct {
+		Collection *struct{} `xml:"DAV: collection"`
+	} `xml:"DAV: resourcetype"`
+	LastModified string `xml:"DAV: getlastmodified"`
+	ETag         string `xml:"DAV: getetag"`
+	CreationDate string `xml:"DAV: creationdate"`
+	Length       int64  `xml:"DAV: getcontentlength"`
+	ContentType  string `xml:"DAV: getcontenttype"`
+}
+
+// источник событий из WebDAV хранилища (Nextcloud, webdav.yandex.ru и т.д.)
+// содержимое папок читается запросами PROPFIND с Depth: 1, изменения определяются сравнением снимков
+type webdavSource struct {
+	*source.Poller
+	baseURL  *url.URL // адрес WebDAV, например https://webdav.yandex.ru
+	username string
+	password string
+	folders  []string // отслеживаемые папки относительно baseURL
+	client   *http.Client
+}
+
+// конструктор источника
+// rawURL - адрес WebDAV, username/password - учетные данные для Basic авторизации,
+// folders - отслеживаемые папки, pause - период опроса, timeout - таймаут запросов
+func NewWebDAVSource(rawURL, username, password string, folders []string, pause, timeout time.Duration) (source.Source, error) {
+	if len(folders) == 0 {
+		return nil, errorApi.ErrNoFolders
+	}
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrInvalidSourceURL, err)
+	}
+	baseURL.Path = strings.TrimSuffix(baseURL.Path, "/")
+	s := &webdavSource{
+		baseURL:  baseURL,
+		username: username,
+		password: password,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+	for _, folder := range folders {
+		s.folders = append(s.folders, models.NormalizeFolder(folder))
+	}
+	s.Poller = source.NewPoller(source.TypeWebDAV, pause, s.scan)
+	return s, nil
+}
+
+// метод делает снимок всех отслеживаемых папок
+func (s *webdavSource) scan() (source.Snapshot, error) {
+	snapshot := make(source.Snapshot)
+	for _, folder := range s.folders {
+		if err := s.scanFolder(folder, snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// метод рекурсивно обходит папку и складывает файлы в snapshot
+func (s *webdavSource) scanFolder(folder string, snapshot source.Snapshot) error {
+	list, err := s.propfind(folder)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errorApi.ErrWalkFolder, folder, err)
+	}
+	for _, resp := range list.Responses {
+		filePath, err := s.relativePath(resp.Href)
+		if err != nil {
+			slog.With(slog.Any("error", err), slog.String("href", resp.Href)).Warn("skip webdav resource")
+			continue
+		}
+		// в ответе с Depth: 1 первым идет сама папка
+		if filePath == folder {
+			continue
+		}
+		p, ok := resp.prop()
+		if !ok {
+			continue
+		}
+		if p.ResourceType.Collection != nil {
+			if err := s.scanFolder(filePath, snapshot); err != nil {
+				return err
+			}
+			continue
+		}
+		modified, _ := http.ParseTime(p.LastModified)
+		created, err := time.Parse(time.RFC3339, p.CreationDate)
+		if err != nil {
+			created = modified
+		}
+		snapshot[filePath] = &models.UpdateInfo{
+			Title:      path.Base(filePath),
+			Path:       filePath,
+			Type:       "file",
+			CreatedAt:  created,
+			ModifiedAt: modified,
+			ETag:       strings.Trim(p.ETag, `"`),
+			Size:       p.Length,
+			MimeType:   p.ContentType,
+		}
+	}
+	return nil
+}
+
+// метод выполняет PROPFIND запрос для папки
+func (s *webdavSource) propfind(folder string) (*multistatus, error) {
+	u := *s.baseURL
+	u.Path = strings.TrimSuffix(s.baseURL.Path+folder, "/") + "/"
+	req, err := http.NewRequest("PROPFIND", u.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrServiceRequest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		slog.With(slog.Int("code", resp.StatusCode)).Debug("bad status code response")
+		return nil, errorApi.ErrInvalidStatusCode
+	}
+	list := &multistatus{}
+	if err := xml.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrUnmarshalXML, err)
+	}
+	return list, nil
+}
+
+// метод переводит href из ответа в путь относительно baseURL: "/папка/файл"
+func (s *webdavSource) relativePath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	p := strings.TrimPrefix(u.Path, s.baseURL.Path)
+	return "/" + strings.Trim(p, "/"), nil
+}
+
+// метод возвращает свойства ресурса со статусом 200
+func (r response) prop() (prop, bool) {
+	for _, ps := range r.Propstats {
+		if strings.Contains(ps.Status, " 200 ") {
+			return ps.Prop, true
+		}
+	}
+	return prop{}, false
+}
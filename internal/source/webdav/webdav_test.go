@@ -0,0 +1,80 @@
+package webdav
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+// функция создает файл в WebDAV хранилище
+func writeFile(t *testing.T, fs webdav.FileSystem, name, content string) {
+	f, err := fs.OpenFile(context.Background(), name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestWebDAVSourcePoll(t *testing.T) {
+	ctx := context.Background()
+	fs := webdav.NewMemFS()
+	srv := httptest.NewServer(&webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer srv.Close()
+
+	require.NoError(t, fs.Mkdir(ctx, "/Общее", 0755))
+	require.NoError(t, fs.Mkdir(ctx, "/Общее/Фото", 0755))
+	require.NoError(t, fs.Mkdir(ctx, "/Личное", 0755))
+	writeFile(t, fs, "/Общее/a.txt", "a")
+	writeFile(t, fs, "/Личное/secret.txt", "secret")
+
+	src, err := NewWebDAVSource(srv.URL+"/dav/", "", "", []string{"/Общее"}, time.Second, time.Second)
+	require.NoError(t, err)
+	s := src.(*webdavSource)
+
+	// первый опрос только запоминает файлы
+	assert.Empty(t, s.Poll())
+
+	writeFile(t, fs, "/Общее/Фото/b.jpg", "b")
+	writeFile(t, fs, "/Личное/other.txt", "other")
+	require.NoError(t, fs.RemoveAll(ctx, "/Общее/a.txt"))
+
+	events := s.Poll()
+	require.Len(t, events, 2)
+	assert.Equal(t, models.EventDeleted, events[0].Event)
+	assert.Equal(t, "/Общее/a.txt", events[0].Path)
+	assert.Equal(t, models.EventCreated, events[1].Event)
+	assert.Equal(t, "/Общее/Фото/b.jpg", events[1].Path)
+	assert.Equal(t, "b.jpg", events[1].Title)
+
+	// изменение содержимого меняет ETag
+	writeFile(t, fs, "/Общее/Фото/b.jpg", "bigger content")
+	events = s.Poll()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventModified, events[0].Event)
+
+	assert.Empty(t, s.Poll())
+}
+
+func TestWebDAVSourceScanError(t *testing.T) {
+	srv := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer srv.Close()
+
+	src, err := NewWebDAVSource(srv.URL, "", "", []string{"/missing"}, time.Second, time.Second)
+	require.NoError(t, err)
+	_, err = src.(*webdavSource).scan()
+	require.Error(t, err)
+}
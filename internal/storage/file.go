@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// хранилище в JSON файле
+// все данные держатся в памяти, а после каждого изменения записываются в файл
+type fileStorage struct {
+	*memoryStorage
+	path    string     // путь до файла
+	flushMu sync.Mutex // мьютекс, чтобы записи в файл шли по очереди
+}
+
+// конструктор файлового хранилища, если файл существует - состояние читается из него
+func NewFileStorage(path string) (*fileStorage, error) {
+	s := &fileStorage{
+		memoryStorage: NewMemoryStorage(),
+		path:          path,
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errorApi.ErrLoadStorage, err)
+	}
+	return s, nil
+}
+
+func (s *fileStorage) SaveListener(chatID int64, state bool) error {
+	s.memoryStorage.SaveListener(chatID, state)
+	return s.flush()
+}
+
+func (s *fileStorage) DeleteListener(chatID int64) error {
+	s.memoryStorage.DeleteListener(chatID)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveChat(chatID int64, info models.ChatInfo) error {
+	s.memoryStorage.SaveChat(chatID, info)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveSubscriptions(chatID int64, folders []string) error {
+	s.memoryStorage.SaveSubscriptions(chatID, folders)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveMutes(chatID int64, folders []string) error {
+	s.memoryStorage.SaveMutes(chatID, folders)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveUploadFolder(chatID int64, folder string) error {
+	s.memoryStorage.SaveUploadFolder(chatID, folder)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveMember(userID int64, m models.Member) error {
+	s.memoryStorage.SaveMember(userID, m)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveOwner(userID int64) error {
+	s.memoryStorage.SaveOwner(userID)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveQuotaAlerted(level int) error {
+	s.memoryStorage.SaveQuotaAlerted(level)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveToken(t *models.Token) error {
+	s.memoryStorage.SaveToken(t)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveCursor(keys []string) error {
+	s.memoryStorage.SaveCursor(keys)
+	return s.flush()
+}
+
+func (s *fileStorage) SaveDeadLetter(d models.DeadLetter) error {
+	s.memoryStorage.SaveDeadLetter(d)
+	return s.flush()
+}
+
+func (s *fileStorage) Close() error {
+	return s.flush()
+}
+
+// метод читает состояние из файла
+func (s *fileStorage) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// файла еще нет, начинаем с пустого состояния
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return err
+	}
+	if s.state.Listeners == nil {
+		s.state.Listeners = make(map[int64]bool)
+	}
+	if s.state.Subscriptions == nil {
+		s.state.Subscriptions = make(map[int64][]string)
+	}
+	if s.state.Mutes == nil {
+		s.state.Mutes = make(map[int64][]string)
+	}
+	if s.state.UploadFolders == nil {
+		s.state.UploadFolders = make(map[int64]string)
+	}
+	if s.state.Members == nil {
+		s.state.Members = make(map[int64]models.Member)
+	}
+	if s.state.Chats == nil {
+		s.state.Chats = make(map[int64]models.ChatInfo)
+	}
+	return nil
+}
+
+// метод записывает состояние в файл
+// запись идет во временный файл, который затем переименовывается,
+// чтобы при падении сервиса файл состояния не оказался поврежден
+func (s *fileStorage) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrSaveStorage, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrSaveStorage, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %w", errorApi.ErrSaveStorage, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrSaveStorage, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("%w: %w", errorApi.ErrSaveStorage, err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// состояние сервиса, которое сохраняется в хранилище
+type state struct {
+	Listeners     map[int64]bool            `json:"listeners"`
+	Subscriptions map[int64][]string        `json:"subscriptions"`
+	Mutes         map[int64][]string        `json:"mutes"`
+	UploadFolders map[int64]string          `json:"upload_folders"`
+	Members       map[int64]models.Member   `json:"members"`
+	Chats         map[int64]models.ChatInfo `json:"chats"`
+	Owner         int64                     `json:"owner,omitempty"`
+	QuotaAlerted  int                       `json:"quota_alerted,omitempty"`
+	Token         *models.Token             `json:"token,omitempty"`
+	Cursor        []string                  `json:"cursor"` // nil - курсор еще не создавался
+	DeadLetters   []models.DeadLetter       `json:"dead_letters,omitempty"`
+}
+
+// хранилище в памяти
+type memoryStorage struct {
+	mu    sync.RWMutex
+	state state
+}
+
+// конструктор хранилища в памяти
+func NewMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		state: state{
+			Listeners:     make(map[int64]bool),
+			Subscriptions: make(map[int64][]string),
+			Mutes:         make(map[int64][]string),
+			UploadFolders: make(map[int64]string),
+			Members:       make(map[int64]models.Member),
+			Chats:         make(map[int64]models.ChatInfo),
+		},
+	}
+}
+
+func (s *memoryStorage) Listeners() (map[int64]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// копия мапы, чтобы вызывающий код не менял состояние хранилища
+	listeners := make(map[int64]bool, len(s.state.Listeners))
+	for chatID, state := range s.state.Listeners {
+		listeners[chatID] = state
+	}
+	return listeners, nil
+}
+
+func (s *memoryStorage) SaveListener(chatID int64, state bool) error {
+	s.mu.Lock()
+	s.state.Listeners[chatID] = state
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStorage) DeleteListener(chatID int64) error {
+	s.mu.Lock()
+	delete(s.state.Listeners, chatID)
+	delete(s.state.Subscriptions, chatID)
+	delete(s.state.Mutes, chatID)
+	delete(s.state.UploadFolders, chatID)
+	delete(s.state.Chats, chatID)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStorage) Chats() (map[int64]models.ChatInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chats := make(map[int64]models.ChatInfo, len(s.state.Chats))
+	for chatID, info := range s.state.Chats {
+		chats[chatID] = info
+	}
+	return chats, nil
+}
+
+func (s *memoryStorage) SaveChat(chatID int64, info models.ChatInfo) error {
+	s.mu.Lock()
+	s.state.Chats[chatID] = info
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStorage) Subscriptions() (map[int64][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subscriptions := make(map[int64][]string, len(s.state.Subscriptions))
+	for chatID, folders := range s.state.Subscriptions {
+		subscriptions[chatID] = append([]string{}, folders...)
+	}
+	return subscriptions, nil
+}
+
+func (s *memoryStorage) SaveSubscriptions(chatID int64, folders []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(folders) == 0 {
+		delete(s.state.Subscriptions, chatID)
+		return nil
+	}
+	s.state.Subscriptions[chatID] = append([]string{}, folders...)
+	return nil
+}
+
+func (s *memoryStorage) Mutes() (map[int64][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	mutes := make(map[int64][]string, len(s.state.Mutes))
+	for chatID, folders := range s.state.Mutes {
+		mutes[chatID] = append([]string{}, folders...)
+	}
+	return mutes, nil
+}
+
+func (s *memoryStorage) SaveMutes(chatID int64, folders []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(folders) == 0 {
+		delete(s.state.Mutes, chatID)
+		return nil
+	}
+	s.state.Mutes[chatID] = append([]string{}, folders...)
+	return nil
+}
+
+func (s *memoryStorage) UploadFolders() (map[int64]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	folders := make(map[int64]string, len(s.state.UploadFolders))
+	for chatID, folder := range s.state.UploadFolders {
+		folders[chatID] = folder
+	}
+	return folders, nil
+}
+
+func (s *memoryStorage) SaveUploadFolder(chatID int64, folder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if folder == "" {
+		delete(s.state.UploadFolders, chatID)
+		return nil
+	}
+	s.state.UploadFolders[chatID] = folder
+	return nil
+}
+
+func (s *memoryStorage) Members() (map[int64]models.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	members := make(map[int64]models.Member, len(s.state.Members))
+	for userID, m := range s.state.Members {
+		members[userID] = m
+	}
+	return members, nil
+}
+
+func (s *memoryStorage) SaveMember(userID int64, m models.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.Role == models.RoleGuest {
+		delete(s.state.Members, userID)
+		return nil
+	}
+	s.state.Members[userID] = m
+	return nil
+}
+
+func (s *memoryStorage) Owner() (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Owner, nil
+}
+
+func (s *memoryStorage) SaveOwner(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Owner = userID
+	return nil
+}
+
+func (s *memoryStorage) QuotaAlerted() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.QuotaAlerted, nil
+}
+
+func (s *memoryStorage) SaveQuotaAlerted(level int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.QuotaAlerted = level
+	return nil
+}
+
+func (s *memoryStorage) Token() (*models.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.state.Token == nil {
+		return nil, errorApi.ErrTokenNotExist
+	}
+	t := *s.state.Token
+	return &t, nil
+}
+
+func (s *memoryStorage) SaveToken(t *models.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t == nil {
+		s.state.Token = nil
+		return nil
+	}
+	copyToken := *t
+	s.state.Token = &copyToken
+	return nil
+}
+
+func (s *memoryStorage) Cursor() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.state.Cursor == nil {
+		return nil, errorApi.ErrCursorNotExist
+	}
+	return append([]string{}, s.state.Cursor...), nil
+}
+
+func (s *memoryStorage) SaveCursor(keys []string) error {
+	s.mu.Lock()
+	s.state.Cursor = append([]string{}, keys...)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStorage) DeadLetters() ([]models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.DeadLetter{}, s.state.DeadLetters...), nil
+}
+
+func (s *memoryStorage) SaveDeadLetter(d models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.DeadLetters = append(s.state.DeadLetters, d)
+	// старые записи вытесняются новыми
+	if extra := len(s.state.DeadLetters) - maxDeadLetters; extra > 0 {
+		s.state.DeadLetters = append([]models.DeadLetter{}, s.state.DeadLetters[extra:]...)
+	}
+	return nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+)
+
+// типы хранилищ
+const (
+	TypeFile   = "file"   // хранение состояния в JSON файле
+	TypeMemory = "memory" // хранение состояния в памяти (для тестов)
+)
+
+// сколько последних недоставленных сообщений хранится
+const maxDeadLetters = 100
+
+// интерфейс хранилища состояния сервиса
+type Storage interface {
+	// слушатели
+	Listeners() (map[int64]bool, error)          // получить всех слушателей
+	SaveListener(chatID int64, state bool) error // сохранить состояние слушателя
+	DeleteListener(chatID int64) error           // удалить слушателя вместе с его подписками, папками и сведениями о чате
+	// сведения о чатах слушателей
+	Chats() (map[int64]models.ChatInfo, error)         // получить название, тип и время последней доставки для чатов
+	SaveChat(chatID int64, info models.ChatInfo) error // сохранить сведения о чате
+	// подписки на папки
+	Subscriptions() (map[int64][]string, error)             // получить папки, на которые подписаны чаты
+	SaveSubscriptions(chatID int64, folders []string) error // сохранить папки чата, пустой список - удалить
+	// отключенные папки
+	Mutes() (map[int64][]string, error)             // получить папки, уведомления из которых чаты отключили
+	SaveMutes(chatID int64, folders []string) error // сохранить отключенные папки чата, пустой список - удалить
+	// папки для загрузки файлов из чата
+	UploadFolders() (map[int64]string, error)           // получить папки, выбранные чатами для загрузки файлов
+	SaveUploadFolder(chatID int64, folder string) error // сохранить папку чата, пустая строка - удалить
+	// пользователи с выданным доступом
+	Members() (map[int64]models.Member, error)      // получить пользователей, ключ - user ID
+	SaveMember(userID int64, m models.Member) error // сохранить пользователя, роль guest - удалить
+	// владелец бота
+	Owner() (int64, error)        // получить user ID владельца, 0 - владелец еще не назначен
+	SaveOwner(userID int64) error // сохранить user ID владельца
+	// предупреждения о заполненности Диска
+	QuotaAlerted() (int, error)       // получить последний порог, о котором предупрежден админ, 0 - предупреждений не было
+	SaveQuotaAlerted(level int) error // сохранить последний порог
+	// токен
+	Token() (*models.Token, error)   // получить токен, если токена нет - ErrTokenNotExist
+	SaveToken(t *models.Token) error // сохранить токен
+	// курсор уже отправленных уведомлений
+	Cursor() ([]string, error)      // получить ключи отправленных файлов, если курсора нет - ErrCursorNotExist
+	SaveCursor(keys []string) error // сохранить ключи отправленных файлов
+	// недоставленные сообщения
+	DeadLetters() ([]models.DeadLetter, error) // получить недоставленные сообщения, от старых к новым
+	SaveDeadLetter(d models.DeadLetter) error  // сохранить недоставленное сообщение, хранятся последние maxDeadLetters
+	Close() error                              // закрыть хранилище
+}
+
+// конструктор хранилища по его типу
+func NewStorage(storageType, path string) (Storage, error) {
+	switch storageType {
+	case TypeFile:
+		return NewFileStorage(path)
+	case TypeMemory:
+		return NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errorApi.ErrUnknownStorage, storageType)
+	}
+}
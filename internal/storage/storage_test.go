@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VoC925/tgBotNotice/internal/errorApi"
+	"github.com/VoC925/tgBotNotice/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+
+	_, err := s.Token()
+	require.ErrorIs(t, err, errorApi.ErrTokenNotExist)
+
+	require.NoError(t, s.SaveListener(1, true))
+	require.NoError(t, s.SaveListener(2, false))
+	require.NoError(t, s.DeleteListener(2))
+	listeners, err := s.Listeners()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true}, listeners)
+
+	require.NoError(t, s.SaveToken(&models.Token{Value: "token"}))
+	tok, err := s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token", tok.Value)
+}
+
+func TestFileStorageReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveListener(10, true))
+	require.NoError(t, s.SaveListener(20, false))
+	require.NoError(t, s.SaveToken(&models.Token{Value: "token"}))
+	require.NoError(t, s.SaveQuotaAlerted(90))
+	delivered := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveChat(10, models.ChatInfo{Title: "Отдел", Type: "supergroup", Delivered: delivered}))
+	require.NoError(t, s.SaveChat(20, models.ChatInfo{Title: "@user", Type: "private"}))
+	require.NoError(t, s.DeleteListener(20))
+	require.NoError(t, s.SaveListener(20, false))
+	require.NoError(t, s.Close())
+
+	// состояние должно восстановиться после "перезапуска"
+	s, err = NewFileStorage(path)
+	require.NoError(t, err)
+	listeners, err := s.Listeners()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{10: true, 20: false}, listeners)
+	level, err := s.QuotaAlerted()
+	require.NoError(t, err)
+	assert.Equal(t, 90, level)
+	// сведения о чате удаляются вместе со слушателем
+	chats, err := s.Chats()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]models.ChatInfo{10: {Title: "Отдел", Type: "supergroup", Delivered: delivered}}, chats)
+	tok, err := s.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token", tok.Value)
+}
+
+func TestDeadLettersLimit(t *testing.T) {
+	s := NewMemoryStorage()
+	for i := 0; i < maxDeadLetters+5; i++ {
+		require.NoError(t, s.SaveDeadLetter(models.DeadLetter{ChatID: int64(i)}))
+	}
+	letters, err := s.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, letters, maxDeadLetters)
+	// остаются последние записи
+	assert.Equal(t, int64(5), letters[0].ChatID)
+	assert.Equal(t, int64(maxDeadLetters+4), letters[len(letters)-1].ChatID)
+}
+
+func TestNewStorageUnknownType(t *testing.T) {
+	_, err := NewStorage("unknown", "")
+	require.ErrorIs(t, err, errorApi.ErrUnknownStorage)
+}
+
+func TestUploadFolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := NewFileStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveListener(1, true))
+	require.NoError(t, s.SaveUploadFolder(1, "/Входящие"))
+	require.NoError(t, s.SaveUploadFolder(2, "/Отчеты"))
+	// пустая папка удаляет выбор чата
+	require.NoError(t, s.SaveUploadFolder(2, ""))
+	require.NoError(t, s.Close())
+
+	s, err = NewFileStorage(path)
+	require.NoError(t, err)
+	folders, err := s.UploadFolders()
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{1: "/Входящие"}, folders)
+
+	// папка удаляется вместе со слушателем
+	require.NoError(t, s.DeleteListener(1))
+	folders, err = s.UploadFolders()
+	require.NoError(t, err)
+	assert.Empty(t, folders)
+}